@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// ethanolDensity is the density of pure alcohol in g/ml
+	ethanolDensity = 0.789
+	// standardDrink is the amount of pure alcohol in one standard drink in g
+	standardDrink = 10.0
+)
+
+// alcohol describes the alcohol content of a drink. Dilution by melting ice
+// is not taken into account.
+type alcohol struct {
+	volume float64 // total volume in l
+	pure   float64 // pure alcohol in l
+}
+
+func (a alcohol) abv() float64 {
+	if a.volume == 0 {
+		return 0
+	}
+	return a.pure / a.volume * 100
+}
+
+func (a alcohol) grams() float64 {
+	return a.pure * 1000 * ethanolDensity
+}
+
+func (a alcohol) standardDrinks() float64 {
+	return a.grams() / standardDrink
+}
+
+func (db *DB) getABV() (map[string]float64, error) {
+	abv := make(map[string]float64)
+	rows, err := db.Query("SELECT name, abv FROM ingredients")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var a float64
+
+		if err := rows.Scan(&name, &a); err != nil {
+			return nil, err
+		}
+		abv[name] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	ps, err := db.getParts()
+	if err != nil {
+		return nil, err
+	}
+	rolled := make(map[string]float64)
+	for ing := range ps {
+		rolled[ing] = ps.abv(ing, abv, make(map[string]bool))
+	}
+	for ing, a := range rolled {
+		abv[ing] = a
+	}
+	return abv, nil
+}
+
+func cocktailAlcohol(ingredients map[string]float64, abv map[string]float64) alcohol {
+	var a alcohol
+	for ing, amount := range ingredients {
+		a.volume += amount
+		a.pure += amount * abv[ing] / 100
+	}
+	return a
+}
+
+// festAlcohol returns the alcohol of all cocktails planned in f.
+func (db *DB) festAlcohol(f fest) (alcohol, error) {
+	abv, err := db.getABV()
+	if err != nil {
+		return alcohol{}, err
+	}
+
+	var total alcohol
+	for _, name := range f.cocktails {
+		ingreds, err := db.cocktailIngredients(name)
+		if err != nil {
+			return alcohol{}, err
+		}
+
+		a := cocktailAlcohol(ingreds, abv)
+		total.volume += a.volume * float64(f.cocktailamounts[name])
+		total.pure += a.pure * float64(f.cocktailamounts[name])
+	}
+	return total, nil
+}
+
+func (in *input) printAlcohol(a alcohol) {
+	fmt.Fprintf(in.w, "alcohol\t%.1f %% vol, %.1f g (%.1f standard drinks)\n", a.abv(), a.grams(), a.standardDrinks())
+}
+
+func (in *input) updateABV(db *DB) error {
+	abv, err := db.getABV()
+	if err != nil {
+		return err
+	}
+
+	var numberedInv []string
+	for item := range abv {
+		numberedInv = append(numberedInv, item)
+	}
+	sort.Strings(numberedInv)
+
+	fmt.Fprintf(in.w, "   ingredient\talcohol\n")
+	for i, item := range numberedInv {
+		fmt.Fprintf(in.w, "%d: %s\t%.1f %% vol\n", i, item, abv[item])
+	}
+
+	update, err := in.getInt("Which item do you want to update? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(numberedInv) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+
+	a, err := in.getFloat("How much alcohol does %s contain? [%% vol]: ", numberedInv[update])
+	if err != nil {
+		return err
+	}
+	if a < 0 || a > 100 {
+		return fmt.Errorf("%.1f %% vol is not a valid alcohol content", a)
+	}
+
+	_, err = db.Exec("UPDATE ingredients SET abv = $1 WHERE name = $2", a, numberedInv[update])
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// allergens are flags a cocktail has if any of its ingredients has them.
+var allergens = []string{"lactose", "nuts", "egg white", "gluten", "caffeine"}
+
+// vegan is the flag a cocktail only has if all of its ingredients have it.
+const vegan = "vegan"
+
+func validFlag(flag string) bool {
+	if flag == vegan {
+		return true
+	}
+	for _, a := range allergens {
+		if a == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// ingredientFlags returns the flags of all ingredients, those of house-made
+// ones including the flags of their parts.
+func (db *DB) ingredientFlags() (map[string]map[string]bool, error) {
+	flags, err := db.ownFlags()
+	if err != nil {
+		return nil, err
+	}
+	ps, err := db.getParts()
+	if err != nil {
+		return nil, err
+	}
+
+	rolled := make(map[string]map[string]bool)
+	for ing := range ps {
+		rolled[ing] = ps.flags(ing, flags, make(map[string]bool))
+	}
+	for ing, fs := range rolled {
+		flags[ing] = fs
+	}
+	return flags, nil
+}
+
+// ownFlags returns the flags set for each ingredient itself.
+func (db *DB) ownFlags() (map[string]map[string]bool, error) {
+	rows, err := db.Query("SELECT ingredients.name, ingredientflags.flag FROM ingredientflags JOIN ingredients ON ingredients.id = ingredientflags.ingredient")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flags := make(map[string]map[string]bool)
+	for rows.Next() {
+		var name, flag string
+
+		if err := rows.Scan(&name, &flag); err != nil {
+			return nil, err
+		}
+		if flags[name] == nil {
+			flags[name] = make(map[string]bool)
+		}
+		flags[name][flag] = true
+	}
+	return flags, rows.Err()
+}
+
+// cocktailFlags returns the allergens contained in a cocktail made of the
+// given ingredients and whether it is vegan.
+func cocktailFlags(ingredients map[string]float64, flags map[string]map[string]bool) (contains []string, isVegan bool) {
+	isVegan = len(ingredients) > 0
+	for _, a := range allergens {
+		for ing := range ingredients {
+			if flags[ing][a] {
+				contains = append(contains, a)
+				break
+			}
+		}
+	}
+	for ing := range ingredients {
+		isVegan = isVegan && flags[ing][vegan]
+	}
+	return contains, isVegan
+}
+
+func (in *input) printFlags(ingredients map[string]float64, db *DB) error {
+	flags, err := db.ingredientFlags()
+	if err != nil {
+		return err
+	}
+
+	contains, isVegan := cocktailFlags(ingredients, flags)
+	if len(contains) > 0 {
+		fmt.Fprintf(in.w, "contains\t%s\n", strings.Join(contains, ", "))
+	}
+	if isVegan {
+		fmt.Fprintf(in.w, "vegan\n")
+	}
+	return nil
+}
+
+func (in *input) alterFlags(db *DB) error {
+	ingreds, err := db.getIngredients()
+	if err != nil {
+		return err
+	}
+	sort.Strings(ingreds)
+	flags, err := db.ownFlags()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "   ingredient\tflags\n")
+	for i, ing := range ingreds {
+		var fs []string
+		for f := range flags[ing] {
+			fs = append(fs, f)
+		}
+		sort.Strings(fs)
+		fmt.Fprintf(in.w, "%d: %s\t%s\n", i, ing, strings.Join(fs, ", "))
+	}
+
+	update, err := in.getInt("Which item do you want to update? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(ingreds) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+
+	choice, err := in.getString("Flags of %s [%s, %s; separate with ',']: ", ingreds[update], strings.Join(allergens, ", "), vegan)
+	if err != nil {
+		return err
+	}
+
+	var set []string
+	for _, f := range strings.Split(choice, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !validFlag(f) {
+			return fmt.Errorf("%s is not a valid flag", f)
+		}
+		set = append(set, f)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM ingredientflags WHERE ingredient = (SELECT id FROM ingredients WHERE name = $1)", ingreds[update])
+	if err != nil {
+		return err
+	}
+
+	for _, f := range set {
+		_, err := tx.Exec("INSERT OR IGNORE INTO ingredientflags (ingredient, flag) VALUES ((SELECT id FROM ingredients WHERE name = $1), $2)", ingreds[update], f)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// parts maps house-made ingredients to the amount of each ingredient needed
+// for one liter of it.
+type parts map[string]map[string]float64
+
+func (db *DB) getParts() (parts, error) {
+	rows, err := db.Query("SELECT i.name, p.name, ingredientparts.amount FROM ingredientparts JOIN ingredients i ON i.id = ingredientparts.ingredient JOIN ingredients p ON p.id = ingredientparts.part")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ps := make(parts)
+	for rows.Next() {
+		var ing, part string
+		var amount float64
+
+		if err := rows.Scan(&ing, &part, &amount); err != nil {
+			return nil, err
+		}
+		if ps[ing] == nil {
+			ps[ing] = make(map[string]float64)
+		}
+		ps[ing][part] = amount
+	}
+	return ps, rows.Err()
+}
+
+// cycle returns an error if ing is made of itself.
+func (ps parts) cycle(ing string, path map[string]bool) error {
+	if path[ing] {
+		return fmt.Errorf("%s is made of itself", ing)
+	}
+	path[ing] = true
+	defer delete(path, ing)
+
+	for part := range ps[ing] {
+		if err := ps.cycle(part, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cost returns the price of ing in cents per liter, which is the cost of its
+// parts for house-made ingredients. path holds the ingredients being rolled
+// up. alterParts rejects ingredients made of themselves, should one be anyway,
+// its own price is used where it recurs.
+func (ps parts) cost(ing string, prices map[string]int, path map[string]bool) float64 {
+	if len(ps[ing]) == 0 || path[ing] {
+		return float64(prices[ing])
+	}
+	path[ing] = true
+	defer delete(path, ing)
+
+	var cost float64
+	for part, amount := range ps[ing] {
+		cost += amount * ps.cost(part, prices, path)
+	}
+	return cost
+}
+
+// abv returns the alcohol content of ing in percent by volume, which is that
+// of its parts for house-made ingredients. path is used like in cost.
+func (ps parts) abv(ing string, abv map[string]float64, path map[string]bool) float64 {
+	if len(ps[ing]) == 0 || path[ing] {
+		return abv[ing]
+	}
+	path[ing] = true
+	defer delete(path, ing)
+
+	var a float64
+	for part, amount := range ps[ing] {
+		a += amount * ps.abv(part, abv, path)
+	}
+	return a
+}
+
+// flags returns the flags of ing. House-made ingredients have the allergens
+// of their parts besides their own and are vegan if all parts are. path is
+// used like in cost.
+func (ps parts) flags(ing string, flags map[string]map[string]bool, path map[string]bool) map[string]bool {
+	fs := make(map[string]bool)
+	for f, set := range flags[ing] {
+		fs[f] = set
+	}
+	if len(ps[ing]) == 0 || path[ing] {
+		return fs
+	}
+	path[ing] = true
+	defer delete(path, ing)
+
+	fs[vegan] = true
+	for part := range ps[ing] {
+		pfs := ps.flags(part, flags, path)
+		for _, a := range allergens {
+			fs[a] = fs[a] || pfs[a]
+		}
+		fs[vegan] = fs[vegan] && pfs[vegan]
+	}
+	return fs
+}
+
+// expand adds amount of ing to needs. Needs of house-made ingredients are
+// taken from their stock first, the rest is expanded down to the ingredients
+// that can be bought. stock of house-made ingredients is reduced accordingly.
+func (ps parts) expand(ing string, amount float64, stock, needs map[string]float64, path map[string]bool) error {
+	if len(ps[ing]) == 0 {
+		needs[ing] += amount
+		return nil
+	}
+	if path[ing] {
+		return fmt.Errorf("%s is made of itself", ing)
+	}
+	path[ing] = true
+	defer delete(path, ing)
+
+	use := stock[ing]
+	if use > amount {
+		use = amount
+	}
+	stock[ing] -= use
+	amount -= use
+
+	for part, a := range ps[ing] {
+		if err := ps.expand(part, amount*a, stock, needs, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (in *input) alterParts(db *DB) error {
+	ingreds, err := db.getIngredients()
+	if err != nil {
+		return err
+	}
+	sort.Strings(ingreds)
+	ps, err := db.getParts()
+	if err != nil {
+		return err
+	}
+
+	for i, ing := range ingreds {
+		fmt.Fprintf(in.w, "%d\t%s\n", i, ing)
+	}
+	update, err := in.getInt("Which ingredient is house-made? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(ingreds) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+	ing := ingreds[update]
+
+	recipe := make(map[string]float64)
+	for {
+		fmt.Fprintf(in.w, "%s is made of:\n", ing)
+		for part, amount := range recipe {
+			fmt.Fprintf(in.w, "%s\t%.2f l\n", part, amount)
+		}
+
+		choice, err := in.getString("Which ingredient is %s made of? [press enter when done]: ", ing)
+		if err != nil {
+			return err
+		}
+		if choice == "" {
+			break
+		}
+
+		id, err := strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+		if id < 0 || id >= len(ingreds) {
+			return fmt.Errorf("%d is not a valid choice", id)
+		}
+		amount, err := in.getFloat("How much %s is needed for 1 l of %s? [l]: ", ingreds[id], ing)
+		if err != nil {
+			return err
+		}
+		recipe[ingreds[id]] = amount
+	}
+
+	ps[ing] = recipe
+	if err := ps.cycle(ing, make(map[string]bool)); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM ingredientparts WHERE ingredient = (SELECT id FROM ingredients WHERE name = $1)", ing)
+	if err != nil {
+		return err
+	}
+
+	for part, amount := range recipe {
+		_, err := tx.Exec("INSERT INTO ingredientparts (ingredient, part, amount) VALUES ((SELECT id FROM ingredients WHERE name = $1), (SELECT id FROM ingredients WHERE name = $2), $3)", ing, part, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
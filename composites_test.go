@@ -0,0 +1,151 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+var testParts = parts{
+	"Sugar syrup": {"Sugar": 0.5, "Water": 0.5},
+	"Rum syrup":   {"Sugar syrup": 0.8, "Rum": 0.2},
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		ing       string
+		amount    float64
+		stock     map[string]float64
+		want      map[string]float64
+		wantStock map[string]float64
+	}{
+		{"Rum", 1, map[string]float64{}, map[string]float64{"Rum": 1}, map[string]float64{}},
+		{"Rum syrup", 1, map[string]float64{}, map[string]float64{"Sugar": 0.4, "Water": 0.4, "Rum": 0.2}, map[string]float64{}},
+		{"Rum syrup", 1, map[string]float64{"Sugar syrup": 0.3}, map[string]float64{"Sugar": 0.25, "Water": 0.25, "Rum": 0.2}, map[string]float64{"Sugar syrup": 0}},
+		{"Sugar syrup", 1, map[string]float64{"Sugar syrup": 2}, map[string]float64{}, map[string]float64{"Sugar syrup": 1}},
+	}
+
+	for _, tt := range tests {
+		needs := make(map[string]float64)
+		if err := testParts.expand(tt.ing, tt.amount, tt.stock, needs, make(map[string]bool)); err != nil {
+			t.Errorf("expand(%s, %.2f): %v", tt.ing, tt.amount, err)
+			continue
+		}
+		if !sameAmounts(needs, tt.want) {
+			t.Errorf("expand(%s, %.2f) needs %v, want %v", tt.ing, tt.amount, needs, tt.want)
+		}
+		if !sameAmounts(tt.stock, tt.wantStock) {
+			t.Errorf("expand(%s, %.2f) leaves stock %v, want %v", tt.ing, tt.amount, tt.stock, tt.wantStock)
+		}
+	}
+}
+
+func TestCycle(t *testing.T) {
+	cyclic := parts{
+		"A": {"B": 1},
+		"B": {"A": 0.5, "C": 0.5},
+	}
+
+	tests := []struct {
+		ps  parts
+		ing string
+		err bool
+	}{
+		{testParts, "Rum syrup", false},
+		{testParts, "Rum", false},
+		{cyclic, "A", true},
+		{cyclic, "C", false},
+		{parts{"A": {"A": 1}}, "A", true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.ps.cycle(tt.ing, make(map[string]bool)); (err != nil) != tt.err {
+			t.Errorf("cycle(%s) = %v, want error %t", tt.ing, err, tt.err)
+		}
+		err := tt.ps.expand(tt.ing, 1, map[string]float64{}, map[string]float64{}, make(map[string]bool))
+		if (err != nil) != tt.err {
+			t.Errorf("expand(%s) = %v, want error %t", tt.ing, err, tt.err)
+		}
+	}
+}
+
+func TestCost(t *testing.T) {
+	prices := map[string]int{"Sugar": 200, "Water": 0, "Rum": 2000, "Rum syrup": 9999}
+
+	tests := []struct {
+		ps   parts
+		ing  string
+		want float64
+	}{
+		{testParts, "Rum", 2000},
+		{testParts, "Sugar syrup", 100},
+		{testParts, "Rum syrup", 480},
+		// a recurring ingredient counts with its own price
+		{parts{"Sugar": {"Sugar": 0.5, "Rum": 0.5}}, "Sugar", 1100},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ps.cost(tt.ing, prices, make(map[string]bool)); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("cost(%s) = %.2f, want %.2f", tt.ing, got, tt.want)
+		}
+	}
+}
+
+func TestPartsABV(t *testing.T) {
+	abv := map[string]float64{"Rum": 40}
+
+	tests := []struct {
+		ps   parts
+		ing  string
+		want float64
+	}{
+		{testParts, "Rum", 40},
+		{testParts, "Sugar syrup", 0},
+		{testParts, "Rum syrup", 8},
+		{parts{"Rum": {"Rum": 0.5, "Water": 0.5}}, "Rum", 20},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ps.abv(tt.ing, abv, make(map[string]bool)); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("abv(%s) = %.2f, want %.2f", tt.ing, got, tt.want)
+		}
+	}
+}
+
+func TestPartsFlags(t *testing.T) {
+	flags := map[string]map[string]bool{
+		"Sugar":       {vegan: true},
+		"Water":       {vegan: true},
+		"Rum":         {vegan: true},
+		"Cream":       {"lactose": true},
+		"Sugar syrup": {"nuts": true},
+	}
+
+	tests := []struct {
+		ps    parts
+		ing   string
+		vegan bool
+		want  []string
+	}{
+		{testParts, "Sugar", true, nil},
+		{testParts, "Sugar syrup", true, []string{"nuts"}},
+		{testParts, "Rum syrup", true, []string{"nuts"}},
+		{parts{"Rum cream": {"Rum": 0.5, "Cream": 0.5}}, "Rum cream", false, []string{"lactose"}},
+		{parts{"Cream": {"Cream": 0.5, "Water": 0.5}}, "Cream", false, []string{"lactose"}},
+	}
+
+	for _, tt := range tests {
+		got := tt.ps.flags(tt.ing, flags, make(map[string]bool))
+		if got[vegan] != tt.vegan {
+			t.Errorf("flags(%s) vegan = %t, want %t", tt.ing, got[vegan], tt.vegan)
+		}
+		want := make(map[string]bool)
+		for _, a := range tt.want {
+			want[a] = true
+		}
+		for _, a := range allergens {
+			if got[a] != want[a] {
+				t.Errorf("flags(%s) %s = %t, want %t", tt.ing, a, got[a], want[a])
+			}
+		}
+	}
+}
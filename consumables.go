@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+)
+
+type consumable struct {
+	name       string
+	unit       string
+	price      int
+	perServing float64
+	available  float64
+	cutFrom    string
+	perFruit   float64
+}
+
+func (db *DB) getConsumables() ([]consumable, error) {
+	rows, err := db.Query("SELECT name, unit, price, perserving, available, cutfrom, perfruit FROM consumables ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consumables []consumable
+	for rows.Next() {
+		var c consumable
+
+		if err := rows.Scan(&c.name, &c.unit, &c.price, &c.perServing, &c.available, &c.cutFrom, &c.perFruit); err != nil {
+			return nil, err
+		}
+		consumables = append(consumables, c)
+	}
+	return consumables, rows.Err()
+}
+
+func (db *DB) cocktailConsumables(cocktail string) (map[string]float64, error) {
+	rows, err := db.Query("SELECT consumables.name, cocktailconsumables.amount FROM cocktailconsumables JOIN consumables ON consumables.id = cocktailconsumables.consumable WHERE cocktail = (SELECT id FROM cocktails WHERE name = $1)", cocktail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consumables := make(map[string]float64)
+	for rows.Next() {
+		var name string
+		var amount float64
+
+		if err := rows.Scan(&name, &amount); err != nil {
+			return nil, err
+		}
+		consumables[name] = amount
+	}
+	return consumables, rows.Err()
+}
+
+// consumableNeeds returns how many units of each consumable are needed for
+// the cocktails planned in f.
+func (db *DB) consumableNeeds(f fest) (map[string]float64, error) {
+	consumables, err := db.getConsumables()
+	if err != nil {
+		return nil, err
+	}
+
+	var served int
+	for _, a := range f.cocktailamounts {
+		served += a
+	}
+
+	needs := make(map[string]float64)
+	for _, c := range consumables {
+		needs[c.name] = c.perServing * float64(served)
+	}
+
+	for _, name := range f.cocktails {
+		cs, err := db.cocktailConsumables(name)
+		if err != nil {
+			return nil, err
+		}
+		for c, amount := range cs {
+			needs[c] += amount * float64(f.cocktailamounts[name])
+		}
+	}
+	return needs, nil
+}
+
+// addConsumables adds the consumables that have to be bought for f to list.
+func (db *DB) addConsumables(list shoppinglist, f fest) error {
+	consumables, err := db.getConsumables()
+	if err != nil {
+		return err
+	}
+	needs, err := db.consumableNeeds(f)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range consumables {
+		need := needs[c.name] - c.available
+		if need < 0 {
+			need = 0
+		}
+		list[c.name] = shoppingItem{
+			amount:     need,
+			unit:       c.unit,
+			price:      need * float64(c.price),
+			consumable: true,
+		}
+	}
+	return nil
+}
+
+func (in *input) listConsumables(db *DB) error {
+	consumables, err := db.getConsumables()
+	if err != nil {
+		return err
+	}
+	if len(consumables) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(in.w, "\nnumber\tconsumable\tavailable\tprice\tper cocktail\n")
+	for i, c := range consumables {
+		fmt.Fprintf(in.w, "%d\t%s\t%.2f %s\t%.2f €\t%.2f %s\n", i, c.name, c.available, c.unit, float64(c.price)/100, c.perServing, c.unit)
+	}
+	return nil
+}
+
+func (in *input) addConsumable(db *DB) error {
+	var c consumable
+	var err error
+
+	c.name, err = in.getString("name of consumable: ")
+	if err != nil {
+		return err
+	}
+	c.unit, err = in.getString("unit [e.g. kg, pcs]: ")
+	if err != nil {
+		return err
+	}
+	c.price, err = in.getInt("price [ct/%s]: ", c.unit)
+	if err != nil {
+		return err
+	}
+	c.perServing, err = in.getFloat("needed for every cocktail [%s, 0 if it depends on the cocktail]: ", c.unit)
+	if err != nil {
+		return err
+	}
+	c.available, err = in.getFloat("available [%s]: ", c.unit)
+	if err != nil {
+		return err
+	}
+	c.cutFrom, err = in.getString("cut from which fruit? [press enter if not cut]: ")
+	if err != nil {
+		return err
+	}
+	if c.cutFrom != "" {
+		c.perFruit, err = in.getFloat("%s per %s [%s]: ", c.name, c.cutFrom, c.unit)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec("INSERT INTO consumables (name, unit, price, perserving, available, cutfrom, perfruit) VALUES ($1, $2, $3, $4, $5, $6, $7)", c.name, c.unit, c.price, c.perServing, c.available, c.cutFrom, c.perFruit)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) updateConsumable(db *DB) error {
+	if err := in.listConsumables(db); err != nil {
+		return err
+	}
+	consumables, err := db.getConsumables()
+	if err != nil {
+		return err
+	}
+
+	update, err := in.getInt("Which consumable do you want to update? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(consumables) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+	c := consumables[update]
+
+	avail, err := in.getFloat("How much is available? [%s]: ", c.unit)
+	if err != nil {
+		return err
+	}
+	price, err := in.getInt("What is the current price? [ct/%s]: ", c.unit)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE consumables SET available = $1, price = $2 WHERE name = $3", avail, price, c.name)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) alterConsumables(name string, db *DB) error {
+	consumables, err := db.getConsumables()
+	if err != nil {
+		return err
+	}
+	current, err := db.cocktailConsumables(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "Consumables for %s:\n", name)
+	for i, c := range consumables {
+		fmt.Fprintf(in.w, "%d %s\t%.2f %s\n", i, c.name, current[c.name]+c.perServing, c.unit)
+	}
+
+	alter, err := in.getInt("Which consumable do you want to alter? ")
+	if err != nil {
+		return err
+	}
+	if alter < 0 || alter >= len(consumables) {
+		return fmt.Errorf("%d is not a valid choice", alter)
+	}
+	c := consumables[alter]
+
+	amount, err := in.getFloat("How much %s does a %s need in addition to the %.2f every cocktail needs [%s]? ", c.name, name, c.perServing, c.unit)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM cocktailconsumables WHERE consumable = (SELECT id FROM consumables WHERE name = $1) AND cocktail = (SELECT id FROM cocktails WHERE name = $2)", c.name, name)
+	if err != nil {
+		return err
+	}
+
+	if amount > 0 {
+		_, err = tx.Exec("INSERT INTO cocktailconsumables (consumable, cocktail, amount) VALUES ((SELECT id FROM consumables WHERE name = $1), (SELECT id FROM cocktails WHERE name = $2), $3)", c.name, name, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
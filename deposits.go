@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+type bottle struct {
+	unit    float64
+	deposit int
+}
+
+// bottles returns how many bottles of size unit are needed for amount liters.
+func bottles(amount, unit float64) int {
+	if unit <= 0 || amount <= 0 {
+		return 0
+	}
+	return int(math.Ceil(amount/unit - 1e-9))
+}
+
+type festDeposits struct {
+	cupDeposit      int
+	cupsIssued      int
+	cupsReturned    int
+	depositReturned int
+}
+
+// cupsKept returns the cup deposit of all cups that were not brought back.
+// More cups can come back than were handed out, e.g. ones from another fest,
+// which does not make for less kept than none.
+func (d festDeposits) cupsKept() int {
+	if d.cupsReturned >= d.cupsIssued {
+		return 0
+	}
+	return (d.cupsIssued - d.cupsReturned) * d.cupDeposit
+}
+
+func (db *DB) getBottles() (map[string]bottle, error) {
+	bottles := make(map[string]bottle)
+	rows, err := db.Query("SELECT name, unit, deposit FROM ingredients")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var b bottle
+
+		if err := rows.Scan(&name, &b.unit, &b.deposit); err != nil {
+			return nil, err
+		}
+		bottles[name] = b
+	}
+	return bottles, rows.Err()
+}
+
+// depositList returns the bottle deposit in cents that has to be paid when
+// buying the given shopping list.
+func (db *DB) depositList(list shoppinglist) (map[string]int, error) {
+	bs, err := db.getBottles()
+	if err != nil {
+		return nil, err
+	}
+
+	deposits := make(map[string]int)
+	for ing, it := range list {
+		if it.consumable {
+			continue
+		}
+		deposits[ing] = bottles(it.amount, bs[ing].unit) * bs[ing].deposit
+	}
+	return deposits, nil
+}
+
+// depositPaid returns the bottle deposit in cents paid for all goods received
+// for the given fest.
+func (db *DB) depositPaid(date string) (int, error) {
+	purchases, err := db.getPurchases(date)
+	if err != nil {
+		return 0, err
+	}
+	bs, err := db.getBottles()
+	if err != nil {
+		return 0, err
+	}
+
+	var paid int
+	for _, p := range purchases {
+		for _, it := range p.items {
+			paid += bottles(it.received, bs[it.ingredient].unit) * bs[it.ingredient].deposit
+		}
+	}
+	return paid, nil
+}
+
+func (db *DB) getFestDeposits(date string) (festDeposits, error) {
+	var d festDeposits
+	err := db.QueryRow("SELECT cupdeposit, cupsissued, cupsreturned, depositreturned FROM fests WHERE date = $1", date).Scan(&d.cupDeposit, &d.cupsIssued, &d.cupsReturned, &d.depositReturned)
+	if err != nil {
+		return festDeposits{}, err
+	}
+	return d, nil
+}
+
+func (in *input) updateDeposit(db *DB) error {
+	bs, err := db.getBottles()
+	if err != nil {
+		return err
+	}
+
+	var numberedInv []string
+	for item := range bs {
+		numberedInv = append(numberedInv, item)
+	}
+	sort.Strings(numberedInv)
+
+	fmt.Fprintf(in.w, "   ingredient\tbottle\tdeposit\n")
+	for i, item := range numberedInv {
+		fmt.Fprintf(in.w, "%d: %s\t%.2f l\t%.2f €\n", i, item, bs[item].unit, float64(bs[item].deposit)/100)
+	}
+
+	update, err := in.getInt("Which item do you want to update? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(numberedInv) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+
+	unit, err := in.getFloat("How big is one bottle of %s? [l]: ", numberedInv[update])
+	if err != nil {
+		return err
+	}
+	deposit, err := in.getInt("What is the deposit for one bottle? [ct]: ")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE ingredients SET unit = $1, deposit = $2 WHERE name = $3", unit, deposit, numberedInv[update])
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) setFestDeposits(db *DB) error {
+	d, err := db.getFestDeposits(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "cup deposit\t%.2f €\n", float64(d.cupDeposit)/100)
+	fmt.Fprintf(in.w, "cups issued\t%d\n", d.cupsIssued)
+	fmt.Fprintf(in.w, "cups returned\t%d\n", d.cupsReturned)
+	fmt.Fprintf(in.w, "bottle deposit returned\t%.2f €\n", float64(d.depositReturned)/100)
+
+	d.cupDeposit, err = in.getInt("What is the deposit for one cup? [ct]: ")
+	if err != nil {
+		return err
+	}
+	d.cupsIssued, err = in.getInt("How many cups were handed out? ")
+	if err != nil {
+		return err
+	}
+	d.cupsReturned, err = in.getInt("How many cups were brought back? ")
+	if err != nil {
+		return err
+	}
+	d.depositReturned, err = in.getInt("How much bottle deposit did you get back? [ct]: ")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE fests SET cupdeposit = $1, cupsissued = $2, cupsreturned = $3, depositreturned = $4 WHERE date = $5", d.cupDeposit, d.cupsIssued, d.cupsReturned, d.depositReturned, cfg.Current)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestCupsKept(t *testing.T) {
+	tests := []struct {
+		d    festDeposits
+		want int
+	}{
+		{festDeposits{cupDeposit: 200, cupsIssued: 100, cupsReturned: 80}, 4000},
+		{festDeposits{cupDeposit: 200, cupsIssued: 100, cupsReturned: 100}, 0},
+		{festDeposits{cupDeposit: 200, cupsIssued: 100, cupsReturned: 120}, 0},
+		{festDeposits{cupDeposit: 0, cupsIssued: 100, cupsReturned: 20}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.d.cupsKept(); got != tt.want {
+			t.Errorf("%+v.cupsKept() = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// equivalents describes groups of interchangeable ingredients.
+type equivalents struct {
+	group  map[string]string  // ingredient to group, empty if in none
+	factor map[string]float64 // amount of ingredient equal to 1 l of group
+}
+
+func (db *DB) getEquivalents() (equivalents, error) {
+	e := equivalents{
+		group:  make(map[string]string),
+		factor: make(map[string]float64),
+	}
+
+	rows, err := db.Query("SELECT name, equivalent, factor FROM ingredients")
+	if err != nil {
+		return equivalents{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, group string
+		var factor float64
+
+		if err := rows.Scan(&name, &group, &factor); err != nil {
+			return equivalents{}, err
+		}
+		if factor <= 0 {
+			factor = 1
+		}
+		e.group[name] = group
+		e.factor[name] = factor
+	}
+	return e, rows.Err()
+}
+
+// members returns the other ingredients ing can be replaced with.
+func (e equivalents) members(ing string) []string {
+	var members []string
+	if e.group[ing] == "" {
+		return nil
+	}
+	for m, g := range e.group {
+		if g == e.group[ing] && m != ing {
+			members = append(members, m)
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+// pool returns the stock of every group in liters of the group.
+func (e equivalents) pool(stock map[string]float64) map[string]float64 {
+	pool := make(map[string]float64)
+	for ing, avail := range stock {
+		if g := e.group[ing]; g != "" {
+			pool[g] += avail / e.factor[ing]
+		}
+	}
+	return pool
+}
+
+// inStock returns whether ing or one of its substitutes is in stock.
+func (e equivalents) inStock(ing string, stock map[string]float64) bool {
+	if stock[ing] > 0 {
+		return true
+	}
+	g := e.group[ing]
+	return g != "" && e.pool(stock)[g] > 0
+}
+
+// toBuy returns how much of each ingredient has to be bought to cover needs
+// with the given stock. Needs of a group are covered by the stock of all its
+// members first, the rest is bought as the member that is cheapest.
+func (e equivalents) toBuy(needs, stock map[string]float64, prices map[string]int) map[string]float64 {
+	buy := make(map[string]float64)
+	groupNeeds := make(map[string]float64)
+
+	for ing, need := range needs {
+		g := e.group[ing]
+		if g == "" {
+			buy[ing] = need - stock[ing]
+			if buy[ing] < 0 {
+				buy[ing] = 0
+			}
+			continue
+		}
+		groupNeeds[g] += need / e.factor[ing]
+		buy[ing] = 0
+	}
+	for ing := range stock {
+		buy[ing] += 0
+	}
+
+	pool := e.pool(stock)
+	for g, need := range groupNeeds {
+		short := need - pool[g]
+		if short <= 0 {
+			continue
+		}
+
+		cheapest := ""
+		for m, mg := range e.group {
+			if mg != g {
+				continue
+			}
+			price := float64(prices[m]) * e.factor[m]
+			if cheapest == "" || price < float64(prices[cheapest])*e.factor[cheapest] || (price == float64(prices[cheapest])*e.factor[cheapest] && m < cheapest) {
+				cheapest = m
+			}
+		}
+		buy[cheapest] += short * e.factor[cheapest]
+	}
+	return buy
+}
+
+func (in *input) alterEquivalent(db *DB) error {
+	e, err := db.getEquivalents()
+	if err != nil {
+		return err
+	}
+
+	var numberedInv []string
+	for item := range e.group {
+		numberedInv = append(numberedInv, item)
+	}
+	sort.Strings(numberedInv)
+
+	fmt.Fprintf(in.w, "   ingredient\tgroup\tper liter of group\n")
+	for i, item := range numberedInv {
+		fmt.Fprintf(in.w, "%d: %s\t%s\t%.2f\n", i, item, e.group[item], e.factor[item])
+	}
+
+	update, err := in.getInt("Which item do you want to update? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(numberedInv) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+	ing := numberedInv[update]
+
+	group, err := in.getString("Which group of interchangeable ingredients is %s in? [e.g. white rum, press enter for none]: ", ing)
+	if err != nil {
+		return err
+	}
+
+	factor := 1.0
+	if group != "" {
+		factor, err = in.getFloat("How much %s equals 1 l of %s? [l]: ", ing, group)
+		if err != nil {
+			return err
+		}
+		if factor <= 0 {
+			return fmt.Errorf("%.2f is not a valid factor", factor)
+		}
+	}
+
+	_, err = db.Exec("UPDATE ingredients SET equivalent = $1, factor = $2 WHERE name = $3", group, factor, ing)
+	if err != nil {
+		return err
+	}
+	return nil
+}
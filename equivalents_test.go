@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// sameAmounts reports whether got and want have the same amounts, treating
+// missing ingredients as 0.
+func sameAmounts(got, want map[string]float64) bool {
+	for ing, a := range got {
+		if math.Abs(a-want[ing]) > 1e-9 {
+			return false
+		}
+	}
+	for ing, a := range want {
+		if math.Abs(a-got[ing]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestToBuy(t *testing.T) {
+	e := equivalents{
+		group:  map[string]string{"Havana": "white rum", "Bacardi": "white rum", "Lime juice": ""},
+		factor: map[string]float64{"Havana": 1, "Bacardi": 1},
+	}
+	prices := map[string]int{"Havana": 1500, "Bacardi": 1200, "Lime juice": 300}
+
+	tests := []struct {
+		name         string
+		needs, stock map[string]float64
+		want         map[string]float64
+	}{
+		{"without group", map[string]float64{"Lime juice": 2}, map[string]float64{"Lime juice": 0.5}, map[string]float64{"Lime juice": 1.5}},
+		{"enough stock", map[string]float64{"Lime juice": 2}, map[string]float64{"Lime juice": 3}, map[string]float64{}},
+		{"cheapest member", map[string]float64{"Havana": 2}, map[string]float64{"Havana": 0.5}, map[string]float64{"Bacardi": 1.5}},
+		{"stock of other member", map[string]float64{"Havana": 2}, map[string]float64{"Bacardi": 2.5}, map[string]float64{}},
+	}
+
+	for _, tt := range tests {
+		if got := e.toBuy(tt.needs, tt.stock, prices); !sameAmounts(got, tt.want) {
+			t.Errorf("%s: toBuy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMembers(t *testing.T) {
+	e := equivalents{group: map[string]string{"Havana": "white rum", "Bacardi": "white rum", "Gin": ""}}
+
+	if got := e.members("Havana"); len(got) != 1 || got[0] != "Bacardi" {
+		t.Errorf("members(Havana) = %v, want [Bacardi]", got)
+	}
+	if got := e.members("Gin"); got != nil {
+		t.Errorf("members(Gin) = %v, want none", got)
+	}
+}
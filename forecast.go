@@ -0,0 +1,301 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const (
+	// defaultPerGuest is the number of cocktails per guest assumed if
+	// there are no past fests to learn from.
+	defaultPerGuest = 2.0
+	// defaultUncertainty is the relative range of a forecast that is based
+	// on less than two past fests.
+	defaultUncertainty = 0.5
+	// cannibalisation is the share of its demand a cocktail loses to every
+	// similar cocktail, one sharing a tag, on the same menu.
+	cannibalisation = 0.5
+)
+
+// forecast is the expected number of cocktails sold and its range.
+type forecast struct {
+	expected, low, high float64
+	// fests is the number of past fests the forecast is based on
+	fests int
+}
+
+// pastSale is how many of a cocktail were sold at a past fest. Planned
+// amounts are used for fests without counted sales, the awaited guests or
+// cfg.Awaited for fests without counted guests.
+type pastSale struct {
+	fest     string
+	guests   int
+	cocktail string
+	sold     int
+}
+
+func (db *DB) pastSales() ([]pastSale, error) {
+	rows, err := db.Query("SELECT fests.date, fests.guests, fests.awaited, cocktails.name, festcocktails.amount, festcocktails.sold FROM festcocktails JOIN fests ON fests.id = festcocktails.fest JOIN cocktails ON cocktails.id = festcocktails.cocktails WHERE fests.date != $1", cfg.Current)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sales []pastSale
+	for rows.Next() {
+		var s pastSale
+		var guests, awaited, sold sql.NullInt64
+
+		if err := rows.Scan(&s.fest, &guests, &awaited, &s.cocktail, &s.sold, &sold); err != nil {
+			return nil, err
+		}
+		switch {
+		case guests.Int64 > 0:
+			s.guests = int(guests.Int64)
+		case awaited.Int64 > 0:
+			s.guests = int(awaited.Int64)
+		default:
+			s.guests = cfg.Awaited
+		}
+		if sold.Valid {
+			s.sold = int(sold.Int64)
+		}
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
+
+// similar returns how many of the other cocktails in menu share a tag with c.
+func similar(c string, menu []string, tags map[string][]string) int {
+	var n int
+	for _, o := range menu {
+		if o == c {
+			continue
+		}
+	tags:
+		for _, t := range tags[c] {
+			for _, ot := range tags[o] {
+				if t == ot {
+					n++
+					break tags
+				}
+			}
+		}
+	}
+	return n
+}
+
+// meanCV returns the mean and the coefficient of variation of xs. The
+// coefficient is defaultUncertainty if there are less than two values.
+func meanCV(xs []float64) (mean, cv float64) {
+	if len(xs) == 0 {
+		return 0, defaultUncertainty
+	}
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	if len(xs) < 2 || mean == 0 {
+		return mean, defaultUncertainty
+	}
+
+	var v float64
+	for _, x := range xs {
+		v += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(v/float64(len(xs)-1)) / mean
+}
+
+// forecastMenu forecasts the sales of every cocktail in menu at a fest with
+// the given number of guests.
+//
+// The cocktails per guest are the mean of all past fests. They are split
+// among the menu by popularity, which is a cocktail's share of the sales of a
+// past fest times the size of its menu, so 1 is average. A cocktail shares
+// its demand with similar cocktails on the same menu, so popularity is
+// corrected for those on past menus and reduced for those on menu.
+func (db *DB) forecastMenu(menu []string, guests int) (map[string]forecast, error) {
+	sales, err := db.pastSales()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := db.cocktailTags()
+	if err != nil {
+		return nil, err
+	}
+
+	total := make(map[string]int)
+	menus := make(map[string][]string)
+	people := make(map[string]int)
+	for _, s := range sales {
+		total[s.fest] += s.sold
+		menus[s.fest] = append(menus[s.fest], s.cocktail)
+		people[s.fest] = s.guests
+	}
+
+	var perGuest []float64
+	for f, t := range total {
+		perGuest = append(perGuest, float64(t)/float64(people[f]))
+	}
+	drinks, drinksCV := meanCV(perGuest)
+	if len(perGuest) == 0 {
+		drinks = defaultPerGuest
+	}
+
+	popularity := make(map[string][]float64)
+	for _, s := range sales {
+		if total[s.fest] == 0 {
+			continue
+		}
+		p := float64(s.sold) / float64(total[s.fest]) * float64(len(menus[s.fest]))
+		p *= 1 + cannibalisation*float64(similar(s.cocktail, menus[s.fest], tags))
+		popularity[s.cocktail] = append(popularity[s.cocktail], p)
+	}
+
+	weights := make(map[string]float64)
+	cvs := make(map[string]float64)
+	var sum float64
+	for _, c := range menu {
+		p, cv := meanCV(popularity[c])
+		if len(popularity[c]) == 0 {
+			p = 1
+		}
+		weights[c] = p / (1 + cannibalisation*float64(similar(c, menu, tags)))
+		cvs[c] = math.Sqrt(cv*cv + drinksCV*drinksCV)
+		sum += weights[c]
+	}
+
+	forecasts := make(map[string]forecast)
+	for _, c := range menu {
+		e := drinks * float64(guests) * weights[c] / sum
+		forecasts[c] = forecast{
+			expected: e,
+			low:      math.Max(0, e*(1-cvs[c])),
+			high:     e * (1 + cvs[c]),
+			fests:    len(popularity[c]),
+		}
+	}
+	return forecasts, nil
+}
+
+func (fc forecast) String() string {
+	return fmt.Sprintf("%.0f (%.0f–%.0f, %d fests)", fc.expected, fc.low, fc.high, fc.fests)
+}
+
+// getPlanned asks how many of cocktail are planned and proposes the forecast
+// for it if menu is served to cfg.Awaited guests.
+func (in *input) getPlanned(db *DB, cocktail string, menu []string) (int, error) {
+	forecasts, err := db.forecastMenu(menu, cfg.Awaited)
+	if err != nil {
+		return 0, err
+	}
+	fc := forecasts[cocktail]
+
+	choice, err := in.getString("How many %s are you planning for? [forecast %s, press enter to use it]: ", cocktail, fc)
+	if err != nil {
+		return 0, err
+	}
+	if choice == "" {
+		return int(math.Round(fc.expected)), nil
+	}
+	return strconv.Atoi(choice)
+}
+
+// printOutliers prints a warning for every cocktail in f that is planned
+// outside of the range of its forecast.
+func (in *input) printOutliers(db *DB, f fest) error {
+	forecasts, err := db.forecastMenu(f.cocktails, f.guests())
+	if err != nil {
+		return err
+	}
+
+	for _, c := range f.cocktails {
+		fc := forecasts[c]
+		a := float64(f.cocktailamounts[c])
+		if a < math.Floor(fc.low) || a > math.Ceil(fc.high) {
+			fmt.Fprintf(in.w, "Warning: %d %s are planned, the forecast is %s.\n", f.cocktailamounts[c], c, fc)
+		}
+	}
+	return nil
+}
+
+func (in *input) recordSales(db *DB) error {
+	dates, err := db.festDates()
+	if err != nil {
+		return err
+	}
+	for i, d := range dates {
+		fmt.Fprintf(in.w, "%d %s\n", i, d)
+	}
+
+	id, err := in.getInt("For which fest do you want to record sales? ")
+	if err != nil {
+		return err
+	}
+	if id < 0 || id >= len(dates) {
+		return fmt.Errorf("%d is not a valid choice", id)
+	}
+
+	f, err := db.getFest(dates[id])
+	if err != nil {
+		return err
+	}
+
+	choice, err := in.getString("How many guests came? [press enter if not counted]: ")
+	if err != nil {
+		return err
+	}
+	var guests int
+	if choice != "" {
+		guests, err = strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+		if guests <= 0 {
+			return fmt.Errorf("%d is not a valid number of guests", guests)
+		}
+	}
+
+	sold := make(map[string]int)
+	for _, c := range f.cocktails {
+		choice, err := in.getString("How many %s were sold? [%d planned, press enter to skip]: ", c, f.cocktailamounts[c])
+		if err != nil {
+			return err
+		}
+		if choice == "" {
+			continue
+		}
+		sold[c], err = strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if guests > 0 {
+		_, err = tx.Exec("UPDATE fests SET guests = $1 WHERE date = $2", guests, f.date)
+		if err != nil {
+			return err
+		}
+	}
+
+	for c, n := range sold {
+		_, err = tx.Exec("UPDATE festcocktails SET sold = $1 WHERE fest = (SELECT id FROM fests WHERE date = $2) AND cocktails = (SELECT id FROM cocktails WHERE name = $3)", n, f.date, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
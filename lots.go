@@ -0,0 +1,235 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+type lot struct {
+	id         int
+	ingredient string
+	purchased  time.Time
+	bestBefore time.Time
+	available  float64
+}
+
+func (in *input) getDate(prompt string, values ...interface{}) (time.Time, error) {
+	s, err := in.getString(prompt, values...)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(dateFormat, s)
+}
+
+// getLots returns all lots that are not used up, oldest first.
+func (db *DB) getLots() ([]lot, error) {
+	rows, err := db.Query("SELECT lots.id, ingredients.name, lots.purchased, lots.bestbefore, lots.available FROM lots JOIN ingredients ON ingredients.id = lots.ingredient WHERE lots.available > 0 ORDER BY lots.purchased, lots.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lots []lot
+	for rows.Next() {
+		var l lot
+
+		if err := rows.Scan(&l.id, &l.ingredient, &l.purchased, &l.bestBefore, &l.available); err != nil {
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	return lots, rows.Err()
+}
+
+// festDay returns the day the given fest takes place. If no day is set, today
+// is used, so that at least already expired lots are recognized.
+func (db *DB) festDay(date string) (time.Time, error) {
+	var day sql.NullTime
+	err := db.QueryRow("SELECT day FROM fests WHERE date = $1", date).Scan(&day)
+	if err != nil && err != sql.ErrNoRows {
+		return time.Time{}, err
+	}
+
+	if !day.Valid {
+		return time.Now(), nil
+	}
+	return day.Time, nil
+}
+
+// usableStock returns the stock without the lots that expire before day.
+func (db *DB) usableStock(day time.Time) (map[string]float64, error) {
+	stock, err := db.getStock()
+	if err != nil {
+		return nil, err
+	}
+
+	lots, err := db.getLots()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range lots {
+		if !l.bestBefore.Before(day) {
+			continue
+		}
+		stock[l.ingredient] -= l.available
+		if stock[l.ingredient] < 0 {
+			stock[l.ingredient] = 0
+		}
+	}
+	return stock, nil
+}
+
+// bookStock adds amount liters of ingredient to the stock.
+func bookStock(tx *sql.Tx, ingredient string, amount float64) error {
+	res, err := tx.Exec("UPDATE stock SET available = available + $1 WHERE ingredient = (SELECT id FROM ingredients WHERE name = $2)", amount, ingredient)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	_, err = tx.Exec("INSERT INTO stock (ingredient, date, available) VALUES ((SELECT id FROM ingredients WHERE name = $1), $2, $3)", ingredient, time.Now(), amount)
+	return err
+}
+
+// consumeLots takes amount liters of ingredient out of its lots, oldest first.
+func consumeLots(tx *sql.Tx, ingredient string, amount float64) error {
+	rows, err := tx.Query("SELECT id, available FROM lots WHERE available > 0 AND ingredient = (SELECT id FROM ingredients WHERE name = $1) ORDER BY purchased, id", ingredient)
+	if err != nil {
+		return err
+	}
+
+	left := make(map[int]float64)
+	var ids []int
+	for rows.Next() {
+		var id int
+		var avail float64
+
+		if err := rows.Scan(&id, &avail); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		left[id] = avail
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if amount <= 0 {
+			break
+		}
+
+		used := left[id]
+		if used > amount {
+			used = amount
+		}
+		amount -= used
+
+		_, err := tx.Exec("UPDATE lots SET available = available - $1 WHERE id = $2", used, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (in *input) addLot(db *DB) error {
+	ingreds, err := db.getIngredients()
+	if err != nil {
+		return err
+	}
+
+	for i, item := range ingreds {
+		fmt.Fprintf(in.w, "%d\t%s\n", i, item)
+	}
+
+	id, err := in.getInt("Which ingredient did you buy? ")
+	if err != nil {
+		return err
+	}
+	if id < 0 || id >= len(ingreds) {
+		return fmt.Errorf("%d is not a valid choice", id)
+	}
+
+	amount, err := in.getFloat("How much %s did you buy? [l]: ", ingreds[id])
+	if err != nil {
+		return err
+	}
+	purchased, err := in.getDate("When did you buy it? [YYYY-MM-DD]: ")
+	if err != nil {
+		return err
+	}
+	bestBefore, err := in.getDate("When does it expire? [YYYY-MM-DD]: ")
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO lots (ingredient, purchased, bestbefore, available) VALUES ((SELECT id FROM ingredients WHERE name = $1), $2, $3, $4)", ingreds[id], purchased, bestBefore, amount)
+	if err != nil {
+		return err
+	}
+
+	if err = bookStock(tx, ingreds[id], amount); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// printExpiry warns about lots that expire before the current fest and lists
+// the remaining lots in the order they should be used.
+func (in *input) printExpiry(db *DB) error {
+	lots, err := db.getLots()
+	if err != nil {
+		return err
+	}
+	if len(lots) == 0 {
+		return nil
+	}
+
+	day, err := db.festDay(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "\nlots (use from top to bottom)\tavailable\tbought\tbest before\n")
+	for _, l := range lots {
+		var note string
+		switch {
+		case l.bestBefore.Before(time.Now()):
+			note = "expired!"
+		case l.bestBefore.Before(day):
+			note = fmt.Sprintf("expires before %s!", cfg.Current)
+		}
+		fmt.Fprintf(in.w, "%s\t%.2f\t%s\t%s\t%s\n", l.ingredient, l.available, l.purchased.Format(dateFormat), l.bestBefore.Format(dateFormat), note)
+	}
+
+	return nil
+}
+
+func (in *input) setFestDay(db *DB) error {
+	day, err := in.getDate("When does %s take place? [YYYY-MM-DD]: ", cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE fests SET day = $1 WHERE date = $2", day, cfg.Current)
+	return err
+}
@@ -7,8 +7,10 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -31,11 +33,26 @@ type config struct {
 	Schema   string
 	Current  string
 	Database string
+	MenuHTML string
+	MenuText string
+
+	// ServiceLevel is a fraction in [0,1), e.g. 0.95 for 95 %
+	ServiceLevel float64
+
+	PerBartender float64
+	StaffDrinks  int
+
+	Rules map[string]rule
 }
 
 type cocktail struct {
-	name        string
-	ingredients map[string]float64
+	name         string
+	ingredients  map[string]float64
+	method       string
+	glass        string
+	garnish      string
+	instructions string
+	photo        string
 }
 
 func newCocktail() cocktail {
@@ -44,11 +61,39 @@ func newCocktail() cocktail {
 	}
 }
 
+type shoppingItem struct {
+	amount     float64
+	unit       string
+	price      float64
+	consumable bool
+}
+
+type shoppinglist map[string]shoppingItem
+
+func (l shoppinglist) names() []string {
+	var names []string
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 type fest struct {
 	date            string
 	cocktails       []string
 	cocktailprices  map[string]int
 	cocktailamounts map[string]int
+	// awaited is the number of guests the plan is for, 0 for cfg.Awaited
+	awaited int
+}
+
+// guests returns the number of guests f is planned for.
+func (f fest) guests() int {
+	if f.awaited > 0 {
+		return f.awaited
+	}
+	return cfg.Awaited
 }
 
 func newFest() fest {
@@ -236,11 +281,34 @@ func (db *DB) cocktailIngredients(cocktail string) (ingredients map[string]float
 	}
 	rows.Close()
 
+	base, scale, err := db.cocktailBase(cocktail)
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		return ingredients, nil
+	}
+
+	baseIngredients, err := db.cocktailIngredients(base)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := db.substitutions(cocktail)
+	if err != nil {
+		return nil, err
+	}
+	for ing, amount := range baseIngredients {
+		ing, amount = subs.apply(ing, amount*scale)
+		if ing != "" {
+			ingredients[ing] += amount
+		}
+	}
+
 	return ingredients, nil
 }
 
 func (db *DB) getCocktails() ([]cocktail, error) {
-	rows, err := db.Query("SELECT name FROM cocktails")
+	rows, err := db.Query("SELECT name, method, glass, garnish, instructions, photo FROM cocktails")
 	if err != nil {
 		return nil, err
 	}
@@ -250,7 +318,7 @@ func (db *DB) getCocktails() ([]cocktail, error) {
 	for rows.Next() {
 		c := newCocktail()
 
-		if err := rows.Scan(&c.name); err != nil {
+		if err := rows.Scan(&c.name, &c.method, &c.glass, &c.garnish, &c.instructions, &c.photo); err != nil {
 			return nil, err
 		}
 
@@ -274,8 +342,24 @@ func (in *input) addInventory(db *DB) error {
 	if err != nil {
 		return err
 	}
+	supplier, err := in.getString("supplier: ")
+	if err != nil {
+		return err
+	}
+	unit, err := in.getFloat("bottle size [l]: ")
+	if err != nil {
+		return err
+	}
+	deposit, err := in.getInt("deposit per bottle [ct]: ")
+	if err != nil {
+		return err
+	}
+	abv, err := in.getFloat("alcohol [%% vol]: ")
+	if err != nil {
+		return err
+	}
 
-	_, err = db.Exec("INSERT INTO ingredients (name, price) VALUES ($1, $2)", name, price)
+	_, err = db.Exec("INSERT INTO ingredients (name, price, supplier, unit, deposit, abv) VALUES ($1, $2, $3, $4, $5, $6)", name, price, supplier, unit, deposit, abv)
 	if err != nil {
 		return err
 	}
@@ -318,6 +402,18 @@ func (db *DB) getIngredientPrices() (map[string]int, error) {
 		prices[name] = price
 	}
 
+	ps, err := db.getParts()
+	if err != nil {
+		return nil, err
+	}
+	rolled := make(map[string]int)
+	for ing := range ps {
+		rolled[ing] = int(math.Round(ps.cost(ing, prices, make(map[string]bool))))
+	}
+	for ing, p := range rolled {
+		prices[ing] = p
+	}
+
 	return prices, nil
 }
 
@@ -343,39 +439,6 @@ func (db *DB) getStock() (map[string]float64, error) {
 	return stock, err
 }
 
-func (in *input) updateAvailability(db *DB) error {
-	stock, err := db.getStock()
-	if err != nil {
-		return err
-	}
-	var numberedInv []string
-
-	for item := range stock {
-		numberedInv = append(numberedInv, item)
-	}
-
-	fmt.Fprintf(in.w, "   stock\tavailable [l]")
-	for i, item := range numberedInv {
-		fmt.Fprintf(in.w, "%d: %s\t%.2f\n", i, item, stock[item])
-	}
-
-	update, err := in.getInt("Which item do you want to update? ")
-	if err != nil {
-		return err
-	}
-
-	avail, err := in.getFloat("How much is available? [l]: ")
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec("UPDATE stock SET available = $1 WHERE ingredient = (SELECT id FROM ingredients WHERE name = $2)", avail, numberedInv[update])
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func (in *input) updatePrice(db *DB) error {
 	prices, err := db.getIngredientPrices()
 	if err != nil {
@@ -389,7 +452,7 @@ func (in *input) updatePrice(db *DB) error {
 	}
 	fmt.Fprintf(in.w, "   cocktail\tprice\n")
 	for i, item := range numberedInv {
-		fmt.Fprintf(in.w, "%d: %s\t%.2f\n", i, item, prices[item])
+		fmt.Fprintf(in.w, "%d: %s\t%d\n", i, item, prices[item])
 	}
 
 	update, err := in.getInt("Which item do you want to update? ")
@@ -502,53 +565,67 @@ func (in *input) listInventory(db *DB) error {
 
 	id := 0
 	for item, val := range stock {
-		fmt.Fprintf(in.w, "%d\t%s\t%.2f\t%.2f €\n", id, item, val, prices[item]/100)
+		fmt.Fprintf(in.w, "%d\t%s\t%.2f\t%.2f €\n", id, item, val, float64(prices[item])/100)
 		id++
 	}
 
-	return nil
+	if err = in.listConsumables(db); err != nil {
+		return err
+	}
+	return in.printExpiry(db)
 }
 
-func (db *DB) genShoppingList() (shoppinglist map[string]float64, pricelist map[string]float64, err error) {
-	cocktails, err := db.getCocktails()
+func (db *DB) genShoppingList() (shoppinglist, error) {
+	f, err := db.getFest(cfg.Current)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	stock, err := db.getStock()
+	return db.shoppingListFor(f, true)
+}
+
+// shoppingListFor returns the shopping list for the cocktails planned in f.
+// If safety is set, the amounts are raised to cfg.ServiceLevel by simulation.
+func (db *DB) shoppingListFor(f fest, safety bool) (shoppinglist, error) {
+	d, err := db.getDemand()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	f, err := db.getFest(cfg.Current)
+
+	amounts := make(map[string]float64)
+	for c, a := range f.cocktailamounts {
+		amounts[c] = float64(a)
+	}
+	buy, err := d.buy(amounts)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	shoppinglist = make(map[string]float64)
-
-	for _, c := range cocktails {
-		for z, m := range c.ingredients {
-			shoppinglist[z] += float64(f.cocktailamounts[c.name]) * m
+	if safety && cfg.ServiceLevel > 0 {
+		risks, err := db.simulate(d, f)
+		if err != nil {
+			return nil, err
+		}
+		for ing, r := range risks {
+			buy[ing] = r.quantity
 		}
 	}
 
-	for ing, avail := range stock {
-		shoppinglist[ing] -= avail
-		if shoppinglist[ing] <= 0 {
-			shoppinglist[ing] = 0
+	list := make(shoppinglist)
+
+	for ing, need := range buy {
+		list[ing] = shoppingItem{
+			amount: need,
+			unit:   "l",
+			price:  need * float64(d.prices[ing]),
 		}
 	}
 
-	prices, err := db.getIngredientPrices()
+	err = db.addConsumables(list, f)
 	if err != nil {
-		return nil, nil, err
-	}
-	pricelist = make(map[string]float64)
-
-	for ing, need := range shoppinglist {
-		pricelist[ing] = need * float64(prices[ing])
+		return nil, err
 	}
 
-	return shoppinglist, pricelist, nil
+	return list, nil
 }
 
 func (in *input) setFest(db *DB) error {
@@ -573,6 +650,10 @@ func (in *input) setFest(db *DB) error {
 		if err != nil {
 			return err
 		}
+		err = db.checkChange(fest, fest.without(fest.cocktails[sel]))
+		if err != nil {
+			return err
+		}
 		err = db.festCocktail(fest.cocktails[sel], 0, 0, true)
 		if err != nil {
 			return err
@@ -583,7 +664,7 @@ func (in *input) setFest(db *DB) error {
 			return err
 		}
 
-		amount, err := in.getFloat("How many %s are you planning for? ", fest.cocktails[sel])
+		amount, err := in.getPlanned(db, fest.cocktails[sel], fest.cocktails)
 		if err != nil {
 			return err
 		}
@@ -592,7 +673,11 @@ func (in *input) setFest(db *DB) error {
 			return err
 		}
 
-		err = db.festCocktail(fest.cocktails[sel], amount, price, false)
+		err = db.checkChange(fest, fest.with(fest.cocktails[sel], amount, price))
+		if err != nil {
+			return err
+		}
+		err = db.festCocktail(fest.cocktails[sel], float64(price), amount, false)
 		if err != nil {
 			return err
 		}
@@ -603,9 +688,13 @@ func (in *input) setFest(db *DB) error {
 		if err != nil {
 			return err
 		}
+		match, err := in.searchCocktails(cocktails, db)
+		if err != nil {
+			return err
+		}
 
 		for i, c := range cocktails {
-			if _, ok := fest.cocktailamounts[c.name]; !ok {
+			if _, ok := fest.cocktailamounts[c.name]; !ok && match[c.name] {
 				fmt.Fprintf(in.w, "%d\t%s\n", i, c.name)
 			}
 		}
@@ -621,7 +710,7 @@ func (in *input) setFest(db *DB) error {
 				return err
 			}
 
-			amount, err := in.getInt("How many %s are you planning for? ", cocktails[id].name)
+			amount, err := in.getPlanned(db, cocktails[id].name, append(fest.cocktails, cocktails[id].name))
 			if err != nil {
 				return err
 			}
@@ -630,13 +719,28 @@ func (in *input) setFest(db *DB) error {
 				return err
 			}
 
+			err = db.checkChange(fest, fest.with(cocktails[id].name, amount, int(price)))
+			if err != nil {
+				return err
+			}
 			err = db.festCocktail(cocktails[id].name, price, amount, false)
 			if err != nil {
 				return err
 			}
+
+			fest, err = db.getFest(cfg.Current)
+			if err != nil {
+				return err
+			}
 		}
 	}
-	return nil
+
+	fest, err = db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+	_, err = in.printViolations(db, fest)
+	return err
 }
 
 func (db *DB) festCocktail(name string, price float64, amount int, del bool) error {
@@ -664,7 +768,7 @@ func (db *DB) festCocktail(name string, price float64, amount int, del bool) err
 	} else {
 		for _, c := range fest.cocktails {
 			if c == name {
-				_, err := db.Exec("UPDATE festcocktails SET price = $1, amount = $2 WHERE cocktails = $3 AND fest = (SELECT id FROM fests WHERE date = $2)", price, amount, id, cfg.Current)
+				_, err := db.Exec("UPDATE festcocktails SET price = $1, amount = $2 WHERE cocktails = $3 AND fest = (SELECT id FROM fests WHERE date = $4)", price, amount, id, cfg.Current)
 				if err != nil {
 					return err
 				}
@@ -673,7 +777,7 @@ func (db *DB) festCocktail(name string, price float64, amount int, del bool) err
 		}
 	}
 
-	_, err = db.Exec("INSERT INTO festcocktails SET cocktail = $1, price = $2, amount = $3, fest = (SELECT id FROM fests WHERE date = $4)", id, price, amount, cfg.Current)
+	_, err = db.Exec("INSERT INTO festcocktails (cocktails, price, amount, fest) VALUES ($1, $2, $3, (SELECT id FROM fests WHERE date = $4))", id, price, amount, cfg.Current)
 	if err != nil {
 		return err
 	}
@@ -696,12 +800,20 @@ func (in *input) getInventoryValue(db *DB) error {
 		val += a * float64(prices[i])
 	}
 
+	consumables, err := db.getConsumables()
+	if err != nil {
+		return err
+	}
+	for _, c := range consumables {
+		val += c.available * float64(c.price)
+	}
+
 	fmt.Fprintf(in.w, "Current inventory value: %.2f €\n", val/100)
 	return nil
 }
 
 func (in *input) inventoryMenu(db *DB) error {
-	items := []string{"list inventory [l]", "query inventory value [v]", "add item [i]", "change availability[a]", "change price [p]", "main Menu [press enter]"}
+	items := []string{"list inventory [l]", "query inventory value [v]", "add item [i]", "add purchased lot [b]", "count stock [a]", "change price [p]", "change bottle size and deposit [d]", "mark as mixed in advance [x]", "change alcohol content [z]", "change allergens [f]", "change storage location [o]", "change bottle weights [w]", "change interchangeable group [g]", "change recipe of house-made ingredient [r]", "add consumable [k]", "change consumable [u]", "main Menu [press enter]"}
 	for _, i := range items {
 		fmt.Fprintf(in.w, "%s\n", i)
 	}
@@ -724,14 +836,58 @@ func (in *input) inventoryMenu(db *DB) error {
 		if err = in.addInventory(db); err != nil {
 			return err
 		}
+	case c == "b":
+		if err = in.addLot(db); err != nil {
+			return err
+		}
 	case c == "a":
-		if err = in.updateAvailability(db); err != nil {
+		if err = in.countStock(db); err != nil {
 			return err
 		}
 	case c == "p":
 		if err = in.updatePrice(db); err != nil {
 			return err
 		}
+	case c == "d":
+		if err = in.updateDeposit(db); err != nil {
+			return err
+		}
+	case c == "x":
+		if err = in.toggleBatched(db); err != nil {
+			return err
+		}
+	case c == "z":
+		if err = in.updateABV(db); err != nil {
+			return err
+		}
+	case c == "f":
+		if err = in.alterFlags(db); err != nil {
+			return err
+		}
+	case c == "o":
+		if err = in.updateLocation(db); err != nil {
+			return err
+		}
+	case c == "w":
+		if err = in.updateWeights(db); err != nil {
+			return err
+		}
+	case c == "g":
+		if err = in.alterEquivalent(db); err != nil {
+			return err
+		}
+	case c == "r":
+		if err = in.alterParts(db); err != nil {
+			return err
+		}
+	case c == "k":
+		if err = in.addConsumable(db); err != nil {
+			return err
+		}
+	case c == "u":
+		if err = in.updateConsumable(db); err != nil {
+			return err
+		}
 	case c == "":
 		return nil
 	default:
@@ -742,15 +898,24 @@ func (in *input) inventoryMenu(db *DB) error {
 }
 
 func (in *input) showCocktails(db *DB) error {
-	fmt.Fprintf(in.w, "Currently available cocktails:\n")
-
 	cocktails, err := db.getCocktails()
 	if err != nil {
 		return err
 	}
+	match, err := in.searchCocktails(cocktails, db)
+	if err != nil {
+		return err
+	}
+	tags, err := db.cocktailTags()
+	if err != nil {
+		return err
+	}
 
+	fmt.Fprintf(in.w, "Matching cocktails:\n")
 	for i, c := range cocktails {
-		fmt.Fprintf(in.w, "%d\t%s\n", i, c.name)
+		if match[c.name] {
+			fmt.Fprintf(in.w, "%d\t%s\t%s\n", i, c.name, strings.Join(tags[c.name], ", "))
+		}
 	}
 
 	i, err := in.getInt("Investigate further? Choose a cocktail: ")
@@ -758,9 +923,58 @@ func (in *input) showCocktails(db *DB) error {
 		return err
 	}
 
+	base, scale, err := db.cocktailBase(cocktails[i].name)
+	if err != nil {
+		return err
+	}
+	if base != "" {
+		fmt.Fprintf(in.w, "%s is a variant of %s, scaled by %.2f\n", cocktails[i].name, base, scale)
+	}
+
 	fmt.Fprintf(in.w, "Ingredients for %s:\n", cocktails[i].name)
+	e, err := db.getEquivalents()
+	if err != nil {
+		return err
+	}
 	for k, v := range cocktails[i].ingredients {
 		fmt.Fprintf(in.w, "%s\t%.2f l\n", k, v)
+		if members := e.members(k); len(members) > 0 {
+			fmt.Fprintf(in.w, "  or\t%s\n", strings.Join(members, ", "))
+		}
+	}
+
+	abv, err := db.getABV()
+	if err != nil {
+		return err
+	}
+	in.printAlcohol(cocktailAlcohol(cocktails[i].ingredients, abv))
+	if err = in.printFlags(cocktails[i].ingredients, db); err != nil {
+		return err
+	}
+
+	consumables, err := db.getConsumables()
+	if err != nil {
+		return err
+	}
+	extra, err := db.cocktailConsumables(cocktails[i].name)
+	if err != nil {
+		return err
+	}
+	for _, c := range consumables {
+		if amount := c.perServing + extra[c.name]; amount > 0 {
+			fmt.Fprintf(in.w, "%s\t%.2f %s\n", c.name, amount, c.unit)
+		}
+	}
+
+	c := cocktails[i]
+	fmt.Fprintf(in.w, "\npreparation\t%s\n", c.method)
+	fmt.Fprintf(in.w, "glass\t%s\n", c.glass)
+	fmt.Fprintf(in.w, "garnish\t%s\n", c.garnish)
+	if c.instructions != "" {
+		fmt.Fprintf(in.w, "instructions\t%s\n", c.instructions)
+	}
+	if c.photo != "" {
+		fmt.Fprintf(in.w, "photo\t%s\n", c.photo)
 	}
 
 	return nil
@@ -805,10 +1019,17 @@ func (db *DB) updateIngredients(cocktail, ing string, amount float64) error {
 		return err
 	}
 
-	_, err = tx.Exec("UPDATE cocktailingredients SET amount = $1 WHERE cocktail = $2 AND ingredient = (SELECT id FROM ingredients WHERE name = $3)", amount, id, ing)
+	res, err := tx.Exec("UPDATE cocktailingredients SET amount = $1 WHERE cocktail = $2 AND ingredient = (SELECT id FROM ingredients WHERE name = $3)", amount, id, ing)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if n == 0 {
+		return fmt.Errorf("%s is no ingredient of its own in %s", ing, cocktail)
+	}
 
 	err = tx.Commit()
 	if err != nil {
@@ -830,7 +1051,7 @@ func (in *input) alterCocktail(db *DB) error {
 		fmt.Fprintf(in.w, "%d\t%s\n", i, c.name)
 	}
 
-	alter, err := in.getString("Alter [n]ame or [i]ngredients? ")
+	alter, err := in.getString("Alter [n]ame, [i]ngredients, [r]ecipe, [c]onsumables, [t]ags or [m]enu info? ")
 	if err != nil {
 		return err
 	}
@@ -847,11 +1068,41 @@ func (in *input) alterCocktail(db *DB) error {
 
 		err = in.alterIngredients(cocktails[alter].name, db)
 		return err
+	case alter == "r":
+		alter, err := in.getInt("Which one would you like to alter? ")
+		if err != nil {
+			return err
+		}
+
+		err = in.alterRecipe(cocktails[alter], db)
+		return err
+	case alter == "c":
+		alter, err := in.getInt("Which one would you like to alter? ")
+		if err != nil {
+			return err
+		}
+
+		err = in.alterConsumables(cocktails[alter].name, db)
+		return err
+	case alter == "t":
+		alter, err := in.getInt("Which one would you like to alter? ")
+		if err != nil {
+			return err
+		}
+
+		err = in.alterTags(cocktails[alter].name, db)
+		return err
+	case alter == "m":
+		alter, err := in.getInt("Which one would you like to alter? ")
+		if err != nil {
+			return err
+		}
+
+		err = in.alterMenuInfo(cocktails[alter].name, db)
+		return err
 	default:
 		return fmt.Errorf("%s is not a valid Choice", alter)
 	}
-
-	return nil
 }
 
 func (in *input) alterCocktailName(db *DB) error {
@@ -912,6 +1163,19 @@ func (in *input) alterIngredients(name string, db *DB) error {
 	if err != nil {
 		return err
 	}
+	if alter < 0 || alter >= len(numberedIngreds) {
+		return fmt.Errorf("%d is not a valid choice", alter)
+	}
+
+	steps, err := db.cocktailSteps(name)
+	if err != nil {
+		return err
+	}
+	for _, s := range steps {
+		if s.ingredient == numberedIngreds[alter] && s.inherited {
+			return fmt.Errorf("%s is taken from the base cocktail, change it there", s.ingredient)
+		}
+	}
 
 	amount, err := in.getFloat("How much %s is actually needed [l]? ", numberedIngreds[alter])
 	if err != nil {
@@ -927,7 +1191,7 @@ func (in *input) alterIngredients(name string, db *DB) error {
 }
 
 func (in *input) cocktailMenu(db *DB) error {
-	items := []string{"create cocktail [c]", "list cocktails [l]", "alter cocktail [a]", "main Menu [press enter]"}
+	items := []string{"create cocktail [c]", "create variant of a cocktail [v]", "list cocktails [l]", "alter cocktail [a]", "main Menu [press enter]"}
 	for _, i := range items {
 		fmt.Fprintf(in.w, "%s\n", i)
 	}
@@ -940,6 +1204,9 @@ func (in *input) cocktailMenu(db *DB) error {
 	switch {
 	case c == "c":
 		in.createCocktail(db)
+	case c == "v":
+		err = in.createVariant(db)
+		return err
 	case c == "l":
 		err = in.showCocktails(db)
 		return err
@@ -970,19 +1237,33 @@ func (in *input) currentFest(db *DB) error {
 	ratio := float64(allcs) / float64(cfg.Awaited)
 
 	fmt.Fprintf(in.w, "You are currently planning for %d cocktails and %d guests. That makes for %.2f cocktails/guest\n", allcs, cfg.Awaited, ratio)
-	if ratio <= 1.6 {
-		fmt.Fprintf(in.w, "This ratio should be around 2, it is a bit low.\n")
-	} else if ratio >= 2.4 {
-		fmt.Fprintf(in.w, "This ratio should be around 2, it is a bit high.\n")
-	} else {
-		fmt.Fprintf(in.w, "This ratio should be around 2, so, it is looking good. Remember not to calculate for too many people ;)\n")
+	if err := in.printOutliers(db, fest); err != nil {
+		return err
+	}
+
+	a, err := db.festAlcohol(fest)
+	if err != nil {
+		return err
 	}
+	perGuest := alcohol{a.volume / float64(cfg.Awaited), a.pure / float64(cfg.Awaited)}
+	fmt.Fprintf(in.w, "You are planning to serve %.2f l of pure alcohol, that is %.1f g (%.1f standard drinks) per guest.\n", a.pure, perGuest.grams(), perGuest.standardDrinks())
 
+	bad, err := in.printViolations(db, fest)
+	if err != nil {
+		return err
+	}
+	if !bad {
+		fmt.Fprintf(in.w, "All planning rules are met, so, it is looking good. Remember not to calculate for too many people ;)\n")
+	}
 	return nil
 }
 
 func (in *input) festMenu(db *DB) error {
-	items := []string{"show current fest [c]", "alter current selection [a]", "generate shopping list [g]", "show last fests [l]", "main Menu [press enter]"}
+	if err := in.printPurchases(db); err != nil {
+		return err
+	}
+
+	items := []string{"show current fest [c]", "alter current selection [a]", "draft scenarios [n]", "generate shopping list [g]", "simulate stock-out risks [x]", "create purchase orders [o]", "show purchase orders [p]", "receive goods [r]", "receipts and reimbursements [e]", "deposits [k]", "profit and loss [b]", "print menu card [m]", "recipe cards and prep sheet [s]", "set day of current fest [d]", "show last fests [l]", "record sales [t]", "tally mode [y]", "set hourly profile [h]", "restock schedule [w]", "shifts and helpers [z]", "drink tokens [v]", "happy hours and deals [u]", "main Menu [press enter]"}
 	for _, i := range items {
 		fmt.Fprintf(in.w, "%s\n", i)
 	}
@@ -1004,19 +1285,118 @@ func (in *input) festMenu(db *DB) error {
 			return err
 		}
 	case c == "g":
-		shoppinglist, pricelist, err := db.genShoppingList()
+		list, err := db.genShoppingList()
 		if err != nil {
 			in.w.Flush()
 			return err
 		}
-		err = in.printLists(shoppinglist, pricelist)
+		depositlist, err := db.depositList(list)
+		if err != nil {
+			in.w.Flush()
+			return err
+		}
+		err = in.printLists(list, depositlist)
 		in.w.Flush()
 		return err
+	case c == "o":
+		err := db.createPurchases()
+		if err != nil {
+			return err
+		}
+		err = in.showPurchases(db)
+		if err != nil {
+			return err
+		}
+	case c == "p":
+		err := in.showPurchases(db)
+		if err != nil {
+			return err
+		}
+	case c == "r":
+		err := in.receivePurchase(db)
+		if err != nil {
+			return err
+		}
+	case c == "e":
+		err := in.receiptMenu(db)
+		if err != nil {
+			return err
+		}
+	case c == "k":
+		err := in.setFestDeposits(db)
+		if err != nil {
+			return err
+		}
+	case c == "b":
+		err := in.showPnL(db)
+		if err != nil {
+			return err
+		}
+	case c == "m":
+		err := in.printMenuCard(db)
+		if err != nil {
+			return err
+		}
+	case c == "s":
+		err := in.printRecipes(db)
+		if err != nil {
+			return err
+		}
+	case c == "d":
+		err := in.setFestDay(db)
+		if err != nil {
+			return err
+		}
 	case c == "l":
 		err := in.lastFest(db)
 		if err != nil {
 			return err
 		}
+	case c == "t":
+		err := in.recordSales(db)
+		if err != nil {
+			return err
+		}
+	case c == "n":
+		err := in.scenarioMenu(db)
+		if err != nil {
+			return err
+		}
+	case c == "y":
+		err := in.tally(db)
+		if err != nil {
+			return err
+		}
+	case c == "h":
+		err := in.setProfile(db)
+		if err != nil {
+			return err
+		}
+	case c == "w":
+		err := in.printSchedule(db)
+		if err != nil {
+			return err
+		}
+	case c == "z":
+		err := in.shiftMenu(db)
+		if err != nil {
+			return err
+		}
+	case c == "v":
+		err := in.tokenMenu(db)
+		if err != nil {
+			return err
+		}
+	case c == "u":
+		err := in.pricingMenu(db)
+		if err != nil {
+			return err
+		}
+	case c == "x":
+		err := in.printRisks(db)
+		if err != nil {
+			return err
+		}
 	default:
 	}
 	return nil
@@ -1060,12 +1440,18 @@ func (in *input) mainMenu(db *DB) error {
 	return fmt.Errorf("What do you want to do next?")
 }
 
-func (in *input) printLists(l1 map[string]float64, l2 map[string]float64) error {
-	fmt.Fprintf(in.w, "ingredient\tamount\tprice\n")
+func (in *input) printLists(list shoppinglist, deposits map[string]int) error {
+	fmt.Fprintf(in.w, "item\tamount\tprice\tdeposit\n")
 
-	for name, menge := range l1 {
-		fmt.Fprintf(in.w, "%s\t%.2f\t%.2f €\n", name, menge, l2[name]/100)
+	var price float64
+	var deposit int
+	for _, name := range list.names() {
+		it := list[name]
+		fmt.Fprintf(in.w, "%s\t%.2f %s\t%.2f €\t%.2f €\n", name, it.amount, it.unit, it.price/100, float64(deposits[name])/100)
+		price += it.price
+		deposit += deposits[name]
 	}
+	fmt.Fprintf(in.w, "total\t\t%.2f €\t%.2f €\n", price/100, float64(deposit)/100)
 	in.w.Flush()
 	return nil
 }
@@ -1111,6 +1497,10 @@ func main() {
 		fmt.Println(err)
 		return
 	}
+	if cfg.ServiceLevel < 0 || cfg.ServiceLevel >= 1 {
+		fmt.Printf("ServiceLevel has to be a fraction between 0 and 1, e.g. 0.95 for 95 %%, not %g\n", cfg.ServiceLevel)
+		return
+	}
 
 	//creating Input for user interaction
 	in := newInput(os.Stdin, os.Stdout)
@@ -1121,6 +1511,10 @@ func main() {
 		fmt.Println(err)
 		return
 	}
+	if err = db.upgrade(cfg.Schema); err != nil {
+		fmt.Println("Upgrading the database failed:", err)
+		return
+	}
 
 	//main loop running the menu until quit.
 	for {
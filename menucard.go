@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// defaultMenuHTML is used if no MenuHTML template is configured.
+const defaultMenuHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cocktails – {{.Fest}}</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; }
+h1 { text-align: center; }
+.cocktail { margin-bottom: 1.2em; }
+.name { font-weight: bold; font-size: 1.3em; }
+.price { float: right; font-weight: bold; }
+.ingredients { color: #444; }
+.notes, .abv { font-size: 0.8em; color: #666; }
+</style>
+</head>
+<body>
+<h1>Cocktails – {{.Fest}}</h1>
+{{range .Cocktails}}<div class="cocktail">
+<span class="name">{{.Name}}</span>{{if .AlcoholFree}} (alkoholfrei){{else}} <span class="abv">{{.ABV}}</span>{{end}}{{if .Vegan}} (vegan){{end}}<span class="price">{{.Price}}</span>
+<div class="ingredients">{{join .Ingredients ", "}}</div>
+{{if .Allergens}}<div class="notes">Enthält: {{join .Allergens ", "}}</div>{{end}}
+{{if .Notes}}<div class="notes">{{.Notes}}</div>{{end}}
+</div>
+{{end}}</body>
+</html>
+`
+
+// defaultMenuText is used if no MenuText template is configured. It is used
+// for the PDF menu, see pdf.layout for the available markup.
+const defaultMenuText = `# Cocktails – {{.Fest}}
+{{range .Cocktails}}
+## {{.Name}}{{if .AlcoholFree}} (alkoholfrei){{else}} ({{.ABV}}){{end}}{{if .Vegan}} (vegan){{end}} – {{.Price}}
+{{join .Ingredients ", "}}
+{{if .Allergens}}Enthält: {{join .Allergens ", "}}
+{{end}}{{if .Notes}}{{.Notes}}
+{{end}}{{end}}`
+
+type menuCocktail struct {
+	Name        string
+	Price       string
+	Ingredients []string
+	ABV         string
+	AlcoholFree bool
+	Vegan       bool
+	Allergens   []string
+	Notes       string
+}
+
+type menuCard struct {
+	Fest      string
+	Cocktails []menuCocktail
+}
+
+// formatEuro formats cents the way prices are written on a German menu.
+func formatEuro(cents int) string {
+	if cents < 0 {
+		return "-" + formatEuro(-cents)
+	}
+	return fmt.Sprintf("%d,%02d €", cents/100, cents%100)
+}
+
+func (db *DB) cocktailNotes(name string) (notes string, err error) {
+	err = db.QueryRow("SELECT notes FROM cocktails WHERE name = $1", name).Scan(&notes)
+	return notes, err
+}
+
+func (db *DB) genMenuCard(date string) (menuCard, error) {
+	card := menuCard{Fest: date}
+
+	f, err := db.getFest(date)
+	if err != nil {
+		return menuCard{}, err
+	}
+	abv, err := db.getABV()
+	if err != nil {
+		return menuCard{}, err
+	}
+	flags, err := db.ingredientFlags()
+	if err != nil {
+		return menuCard{}, err
+	}
+
+	for _, name := range f.cocktails {
+		ingreds, err := db.cocktailIngredients(name)
+		if err != nil {
+			return menuCard{}, err
+		}
+
+		c := menuCocktail{
+			Name:  name,
+			Price: formatEuro(f.cocktailprices[name]),
+			ABV:   fmt.Sprintf("%.0f %% vol", cocktailAlcohol(ingreds, abv).abv()),
+		}
+		c.AlcoholFree = cocktailAlcohol(ingreds, abv).pure == 0
+		c.Allergens, c.Vegan = cocktailFlags(ingreds, flags)
+		for ing := range ingreds {
+			c.Ingredients = append(c.Ingredients, ing)
+		}
+		// the main ingredients go first
+		sort.Slice(c.Ingredients, func(i, j int) bool {
+			return ingreds[c.Ingredients[i]] > ingreds[c.Ingredients[j]]
+		})
+
+		c.Notes, err = db.cocktailNotes(name)
+		if err != nil {
+			return menuCard{}, err
+		}
+
+		card.Cocktails = append(card.Cocktails, c)
+	}
+	return card, nil
+}
+
+// loadTemplate returns the content of file, or def if file is not set.
+func loadTemplate(file, def string) (string, error) {
+	if file == "" {
+		return def, nil
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func writeMenuHTML(card menuCard, file string) error {
+	src, err := loadTemplate(cfg.MenuHTML, defaultMenuHTML)
+	if err != nil {
+		return err
+	}
+
+	t, err := htmltemplate.New("menu").Funcs(htmltemplate.FuncMap{"join": strings.Join}).Parse(src)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = t.Execute(f, card); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func writeMenuPDF(card menuCard, file string) error {
+	src, err := loadTemplate(cfg.MenuText, defaultMenuText)
+	if err != nil {
+		return err
+	}
+
+	t, err := template.New("menu").Funcs(template.FuncMap{"join": strings.Join}).Parse(src)
+	if err != nil {
+		return err
+	}
+
+	var text bytes.Buffer
+	if err = t.Execute(&text, card); err != nil {
+		return err
+	}
+
+	var doc pdf
+	doc.layout(text.String())
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = doc.write(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (in *input) printMenuCard(db *DB) error {
+	card, err := db.genMenuCard(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	name, err := in.getString("File name for the menu [without extension]: ")
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = "menu"
+	}
+
+	if err = writeMenuHTML(card, name+".html"); err != nil {
+		return err
+	}
+	if err = writeMenuPDF(card, name+".pdf"); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "Menu written to %s.html and %s.pdf\n", name, name)
+	return nil
+}
+
+func (in *input) alterMenuInfo(name string, db *DB) error {
+	notes, err := db.cocktailNotes(name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(in.w, "notes\t%s\n", notes)
+
+	notes, err = in.getString("Notes for the menu [allergens are taken from the ingredients]: ")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE cocktails SET notes = $1 WHERE name = $2", notes, name)
+	if err != nil {
+		return err
+	}
+	return nil
+}
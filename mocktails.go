@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+)
+
+// alcoholFree returns for every cocktail whether it is alcohol-free, which is
+// the case if none of its ingredients contains alcohol.
+func (db *DB) alcoholFree() (map[string]bool, error) {
+	cocktails, err := db.getCocktails()
+	if err != nil {
+		return nil, err
+	}
+	abv, err := db.getABV()
+	if err != nil {
+		return nil, err
+	}
+
+	free := make(map[string]bool)
+	for _, c := range cocktails {
+		free[c.name] = cocktailAlcohol(c.ingredients, abv).pure == 0
+	}
+	return free, nil
+}
+
+// checkAlcoholFreeShare checks that at least Min of the cocktails in f are
+// alcohol-free.
+func checkAlcoholFreeShare(db *DB, f fest, r rule) (string, error) {
+	if len(f.cocktails) == 0 {
+		return "", nil
+	}
+	free, err := db.alcoholFree()
+	if err != nil {
+		return "", err
+	}
+
+	var n int
+	for _, c := range f.cocktails {
+		if free[c] {
+			n++
+		}
+	}
+
+	share := float64(n) / float64(len(f.cocktails))
+	if share < r.Min {
+		return fmt.Sprintf("only %d of %d cocktails are alcohol-free, there should be at least %.0f %%", n, len(f.cocktails), r.Min*100), nil
+	}
+	return "", nil
+}
+
+// checkAlcoholFreePrices checks that every alcohol-free cocktail in f is
+// cheaper than every alcoholic one.
+func checkAlcoholFreePrices(db *DB, f fest, r rule) (string, error) {
+	free, err := db.alcoholFree()
+	if err != nil {
+		return "", err
+	}
+
+	cheapest := ""
+	for _, c := range f.cocktails {
+		if free[c] {
+			continue
+		}
+		if cheapest == "" || f.cocktailprices[c] < f.cocktailprices[cheapest] {
+			cheapest = c
+		}
+	}
+	if cheapest == "" {
+		return "", nil
+	}
+
+	for _, c := range f.cocktails {
+		if free[c] && f.cocktailprices[c] >= f.cocktailprices[cheapest] {
+			return fmt.Sprintf("alcohol-free %s (%.2f €) has to be cheaper than %s (%.2f €)", c, float64(f.cocktailprices[c])/100, cheapest, float64(f.cocktailprices[cheapest])/100), nil
+		}
+	}
+	return "", nil
+}
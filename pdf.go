@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pdf is a minimal writer for A4 PDF documents containing nothing but text in
+// the standard Helvetica fonts, so no fonts need to be embedded.
+type pdf struct {
+	pages []*bytes.Buffer
+}
+
+const (
+	pdfWidth  = 595
+	pdfHeight = 842
+)
+
+func (p *pdf) addPage() {
+	p.pages = append(p.pages, new(bytes.Buffer))
+}
+
+// text writes s at x, y (measured in points from the top left corner).
+func (p *pdf) text(x, y, size float64, bold bool, s string) {
+	if len(p.pages) == 0 {
+		p.addPage()
+	}
+
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p.pages[len(p.pages)-1], "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, x, pdfHeight-y, pdfEscape(s))
+}
+
+// layout writes text line by line, starting new pages as needed. Lines
+// starting with "# " or "## " are set as headings, long lines are wrapped and
+// a line containing only a form feed starts a new page.
+func (p *pdf) layout(text string) {
+	const margin = 56
+
+	y := float64(pdfHeight)
+	for _, line := range strings.Split(text, "\n") {
+		if line == "\f" {
+			p.addPage()
+			y = margin
+			continue
+		}
+
+		size, bold := 11.0, false
+		switch {
+		case strings.HasPrefix(line, "## "):
+			size, bold = 14, true
+			line = line[3:]
+		case strings.HasPrefix(line, "# "):
+			size, bold = 22, true
+			line = line[2:]
+		}
+
+		// Helvetica is about half as wide as high on average
+		width := int((pdfWidth - 2*margin) / (size * 0.5))
+		for _, l := range wrap(line, width) {
+			if y+size*1.4 > pdfHeight-margin {
+				p.addPage()
+				y = margin
+			}
+			y += size * 1.4
+			p.text(margin, y, size, bold, l)
+		}
+	}
+}
+
+// wrap splits s into lines of at most width characters.
+func wrap(s string, width int) []string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	line := words[0]
+	for _, w := range words[1:] {
+		if len([]rune(line))+1+len([]rune(w)) > width {
+			lines = append(lines, line)
+			line = w
+			continue
+		}
+		line += " " + w
+	}
+	return append(lines, line)
+}
+
+// pdfEscape encodes s in WinAnsiEncoding and escapes it for a PDF string.
+func pdfEscape(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteByte(0x80)
+		case r == '–':
+			b.WriteByte(0x96)
+		case r == '…':
+			b.WriteByte(0x85)
+		case r < 0x20:
+		case r < 0x100:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func (p *pdf) write(w io.Writer) error {
+	if len(p.pages) == 0 {
+		p.addPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(format string, values ...interface{}) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&buf, format, values...)
+		fmt.Fprintf(&buf, "\nendobj\n")
+	}
+
+	// objects 1-4 are catalog, page tree and fonts, then follow page and
+	// content stream for every page.
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids bytes.Buffer
+	for i := range p.pages {
+		fmt.Fprintf(&kids, "%d 0 R ", 5+2*i)
+	}
+	obj("<< /Type /Pages /Kids [ %s] /Count %d >>", kids.String(), len(p.pages))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range p.pages {
+		obj("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfWidth, pdfHeight, 6+2*i)
+		obj("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// pnlLine is one line of the profit and loss statement of a fest. Income is
+// positive, expenses are negative; amounts are in cents.
+type pnlLine struct {
+	name   string
+	amount int
+}
+
+// talliedSales returns what the cocktails sold to guests in tally mode at the
+// fest at date made in cents and how many were sold.
+func (db *DB) talliedSales(date string) (sales, n int, err error) {
+	err = db.QueryRow("SELECT COALESCE(SUM(price), 0), COUNT(*) FROM sales WHERE helper IS NULL AND fest = (SELECT id FROM fests WHERE date = $1)", date).Scan(&sales, &n)
+	return sales, n, err
+}
+
+func (db *DB) festPnL(date string) ([]pnlLine, error) {
+	var lines []pnlLine
+
+	f, err := db.getFest(date)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := db.getTokens(date)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) > 0 {
+		// tokens are only valid at the fest they were sold at
+		var redeemed, unredeemed int
+		for _, t := range tokens {
+			redeemed += t.redeemed * t.value
+			unredeemed += (t.sold - t.redeemed) * t.value
+		}
+		lines = append(lines, pnlLine{"tokens redeemed", redeemed})
+		lines = append(lines, pnlLine{"tokens sold but not redeemed", unredeemed})
+	} else {
+		sales, n, err := db.talliedSales(date)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			lines = append(lines, pnlLine{"cocktail sales (tallied)", sales})
+		} else {
+			// nothing was tallied, so the plan is all there is
+			planned, err := db.expectedRevenue(f)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, pnlLine{"cocktail sales (planned, nothing tallied)", int(math.Round(planned))})
+		}
+	}
+
+	receipts, err := db.getReceipts(date)
+	if err != nil {
+		return nil, err
+	}
+	var spent int
+	for _, r := range receipts {
+		spent += r.amount
+	}
+
+	// the receipts include the bottle deposit paid at the shop
+	paid, err := db.depositPaid(date)
+	if err != nil {
+		return nil, err
+	}
+	d, err := db.getFestDeposits(date)
+	if err != nil {
+		return nil, err
+	}
+	lines = append(lines, pnlLine{"purchases (receipts without deposit)", paid - spent})
+	lines = append(lines, pnlLine{"bottle deposit paid", -paid})
+	lines = append(lines, pnlLine{"bottle deposit returned", d.depositReturned})
+	lines = append(lines, pnlLine{"cup deposit of cups not returned", d.cupsKept()})
+
+	return lines, nil
+}
+
+func (in *input) showPnL(db *DB) error {
+	lines, err := db.festPnL(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	var total int
+	fmt.Fprintf(in.w, "profit and loss for %s\n", cfg.Current)
+	for _, l := range lines {
+		fmt.Fprintf(in.w, "%s\t%.2f €\n", l.name, float64(l.amount)/100)
+		total += l.amount
+	}
+	fmt.Fprintf(in.w, "total\t%.2f €\n", float64(total)/100)
+	return nil
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// priceRule changes the price of a cocktail, or of all cocktails if it is
+// empty, during a window of the night.
+type priceRule struct {
+	id       int
+	name     string
+	cocktail string
+	window
+	// price is the price during the window, NULL to multiply by factor
+	price  sql.NullInt64
+	factor float64
+}
+
+// apply returns the price of cocktail with base price base under r and
+// whether r applies to the cocktail at all.
+func (r priceRule) apply(cocktail string, base int) (int, bool) {
+	if r.cocktail != "" && r.cocktail != cocktail {
+		return base, false
+	}
+	if r.price.Valid {
+		return int(r.price.Int64), true
+	}
+	return int(math.Round(float64(base) * r.factor)), true
+}
+
+// moreSpecific returns whether r is more specific than o: a rule for one
+// cocktail is more specific than one for all cocktails, then the shorter
+// window and then the rule entered later.
+func (r priceRule) moreSpecific(o priceRule) bool {
+	if (r.cocktail != "") != (o.cocktail != "") {
+		return r.cocktail != ""
+	}
+	if r.length() != o.length() {
+		return r.length() < o.length()
+	}
+	return r.id > o.id
+}
+
+// length returns the hours of w.
+func (w window) length() int {
+	return nightOrder(w.finish) - nightOrder(w.start)
+}
+
+func (r priceRule) String() string {
+	what := r.cocktail
+	if what == "" {
+		what = "all cocktails"
+	}
+	if r.price.Valid {
+		return fmt.Sprintf("%s: %s for %s %s", r.name, what, formatEuro(int(r.price.Int64)), r.window)
+	}
+	return fmt.Sprintf("%s: %s at %.0f %% %s", r.name, what, r.factor*100, r.window)
+}
+
+func (db *DB) getPriceRules(date string) ([]priceRule, error) {
+	rows, err := db.Query("SELECT pricerules.id, pricerules.name, cocktails.name, start, finish, price, factor FROM pricerules LEFT JOIN cocktails ON cocktails.id = pricerules.cocktail WHERE fest = (SELECT id FROM fests WHERE date = $1) ORDER BY start", date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []priceRule
+	for rows.Next() {
+		var r priceRule
+		var cocktail sql.NullString
+
+		if err := rows.Scan(&r.id, &r.name, &cocktail, &r.start, &r.finish, &r.price, &r.factor); err != nil {
+			return nil, err
+		}
+		r.cocktail = cocktail.String
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// activePrice returns the price of cocktail in f at hour. That is the price
+// of the most specific rule active then, be it a discount or a surcharge, or
+// its price in f if no rule is.
+func activePrice(f fest, rules []priceRule, cocktail string, hour int) int {
+	price := f.cocktailprices[cocktail]
+	var active *priceRule
+	for i, r := range rules {
+		if !r.covers(hour) {
+			continue
+		}
+		if active != nil && !r.moreSpecific(*active) {
+			continue
+		}
+		if p, ok := r.apply(cocktail, f.cocktailprices[cocktail]); ok {
+			price = p
+			active = &rules[i]
+		}
+	}
+	return price
+}
+
+// expectedRevenue returns the revenue of f in cents if its cocktails are
+// sold over the night following the hourly profile, each at the price
+// active then. Without a profile the prices of f are used. Bundles are left
+// out, as it is not known how many guests take them.
+func (db *DB) expectedRevenue(f fest) (float64, error) {
+	profile, err := db.hourlyProfile(f.date)
+	if err == errNoProfile {
+		var revenue float64
+		for _, c := range f.cocktails {
+			revenue += float64(f.cocktailamounts[c] * f.cocktailprices[c])
+		}
+		return revenue, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	rules, err := db.getPriceRules(f.date)
+	if err != nil {
+		return 0, err
+	}
+
+	var revenue float64
+	for _, c := range f.cocktails {
+		for h, share := range profile {
+			revenue += float64(f.cocktailamounts[c]) * share * float64(activePrice(f, rules, c, h))
+		}
+	}
+	return revenue, nil
+}
+
+// bundle is a deal of several cocktails for one price.
+type bundle struct {
+	id    int
+	name  string
+	price int
+	items map[string]int
+}
+
+func (db *DB) getBundles(date string) ([]bundle, error) {
+	rows, err := db.Query("SELECT id, name, price FROM bundles WHERE fest = (SELECT id FROM fests WHERE date = $1) ORDER BY name", date)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundles []bundle
+	for rows.Next() {
+		b := bundle{items: make(map[string]int)}
+
+		if err := rows.Scan(&b.id, &b.name, &b.price); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bundles = append(bundles, b)
+	}
+	rows.Close()
+
+	for _, b := range bundles {
+		rows, err := db.Query("SELECT cocktails.name, bundlecocktails.amount FROM bundlecocktails JOIN cocktails ON cocktails.id = bundlecocktails.cocktail WHERE bundle = $1", b.id)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var c string
+			var a int
+
+			if err := rows.Scan(&c, &a); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			b.items[c] = a
+		}
+		rows.Close()
+	}
+	return bundles, nil
+}
+
+func (b bundle) String() string {
+	var items []string
+	for _, c := range sortedKeys(b.items) {
+		items = append(items, fmt.Sprintf("%d × %s", b.items[c], c))
+	}
+	return fmt.Sprintf("%s (%s) for %s", b.name, strings.Join(items, ", "), formatEuro(b.price))
+}
+
+func sortedKeys(m map[string]int) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// split returns what each cocktail of b is sold for, sharing the price of b
+// in proportion to the prices of the cocktails in f.
+func (b bundle) split(f fest) []sale {
+	var full int
+	for c, a := range b.items {
+		full += a * f.cocktailprices[c]
+	}
+
+	var sales []sale
+	rest := b.price
+	for _, c := range sortedKeys(b.items) {
+		for i := 0; i < b.items[c]; i++ {
+			p := b.price / b.count()
+			if full > 0 {
+				p = b.price * f.cocktailprices[c] / full
+			}
+			sales = append(sales, sale{c, p})
+			rest -= p
+		}
+	}
+	if len(sales) > 0 {
+		sales[len(sales)-1].price += rest
+	}
+	return sales
+}
+
+func (b bundle) count() int {
+	var n int
+	for _, a := range b.items {
+		n += a
+	}
+	return n
+}
+
+type sale struct {
+	cocktail string
+	price    int
+}
+
+// sellBundle records all cocktails of b as sold now, all or none of them.
+func (db *DB) sellBundle(f fest, b bundle) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, s := range b.split(f) {
+		if err = recordSale(tx, s.cocktail, s.price, ""); err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) addPriceRule(db *DB) error {
+	f, err := db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	name, err := in.getString("Name of the rule [e.g. happy hour]: ")
+	if err != nil {
+		return err
+	}
+	w, err := in.getWindow(name)
+	if err != nil {
+		return err
+	}
+
+	for i, c := range f.cocktails {
+		fmt.Fprintf(in.w, "%d\t%s\t%s\n", i, c, formatEuro(f.cocktailprices[c]))
+	}
+	choice, err := in.getString("Which cocktail is it for? [press enter for all]: ")
+	if err != nil {
+		return err
+	}
+	var cocktail interface{}
+	if choice != "" {
+		sel, err := strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+		if sel < 0 || sel >= len(f.cocktails) {
+			return fmt.Errorf("%d is not a valid choice", sel)
+		}
+		cocktail = f.cocktails[sel]
+	}
+
+	var price interface{}
+	factor := 1.0
+	choice, err = in.getString("Price during %s [ct, 0 for free, or e.g. 80%% or 120%% of the price]: ", name)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(choice, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(choice, "%")), 64)
+		if err != nil {
+			return err
+		}
+		if percent < 0 {
+			return fmt.Errorf("%.0f %% is not a valid share of the price", percent)
+		}
+		factor = percent / 100
+	} else {
+		p, err := strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+		if p < 0 {
+			return fmt.Errorf("%d is not a valid price", p)
+		}
+		price = p
+	}
+
+	_, err = db.Exec("INSERT INTO pricerules (fest, name, cocktail, start, finish, price, factor) VALUES ((SELECT id FROM fests WHERE date = $1), $2, (SELECT id FROM cocktails WHERE name = $3), $4, $5, $6, $7)", cfg.Current, name, cocktail, w.start, w.finish, price, factor)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) addBundle(db *DB) error {
+	f, err := db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	name, err := in.getString("Name of the deal [e.g. 2 for 1]: ")
+	if err != nil {
+		return err
+	}
+	for i, c := range f.cocktails {
+		fmt.Fprintf(in.w, "%d\t%s\t%s\n", i, c, formatEuro(f.cocktailprices[c]))
+	}
+	choice, err := in.getString("Cocktails in %s [separate with ',', repeat for several]: ", name)
+	if err != nil {
+		return err
+	}
+
+	items := make(map[string]int)
+	for _, c := range strings.Split(choice, ",") {
+		sel, err := strconv.Atoi(strings.TrimSpace(c))
+		if err != nil {
+			return err
+		}
+		if sel < 0 || sel >= len(f.cocktails) {
+			return fmt.Errorf("%d is not a valid choice", sel)
+		}
+		items[f.cocktails[sel]]++
+	}
+	price, err := in.getInt("Price of %s [ct]: ", name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO bundles (fest, name, price) VALUES ((SELECT id FROM fests WHERE date = $1), $2, $3)", cfg.Current, name, price)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for c, a := range items {
+		_, err := tx.Exec("INSERT INTO bundlecocktails (bundle, cocktail, amount) VALUES ($1, (SELECT id FROM cocktails WHERE name = $2), $3)", id, c, a)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) listPricing(db *DB) error {
+	rules, err := db.getPriceRules(cfg.Current)
+	if err != nil {
+		return err
+	}
+	bundles, err := db.getBundles(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "Price rules:\n")
+	for i, r := range rules {
+		fmt.Fprintf(in.w, "%d\t%s\n", i, r)
+	}
+	fmt.Fprintf(in.w, "Deals:\n")
+	for i, b := range bundles {
+		fmt.Fprintf(in.w, "%d\t%s\n", i, b)
+	}
+	return nil
+}
+
+func (in *input) deletePricing(db *DB) error {
+	if err := in.listPricing(db); err != nil {
+		return err
+	}
+	rules, err := db.getPriceRules(cfg.Current)
+	if err != nil {
+		return err
+	}
+	bundles, err := db.getBundles(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	choice, err := in.getString("Delete which rule or deal? [e.g. 0 for a rule, d0 for a deal]: ")
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(choice, "d") {
+		sel, err := strconv.Atoi(choice[1:])
+		if err != nil {
+			return err
+		}
+		if sel < 0 || sel >= len(bundles) {
+			return fmt.Errorf("%d is not a valid choice", sel)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		_, err = tx.Exec("DELETE FROM bundlecocktails WHERE bundle = $1", bundles[sel].id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("DELETE FROM bundles WHERE id = $1", bundles[sel].id)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	sel, err := strconv.Atoi(choice)
+	if err != nil {
+		return err
+	}
+	if sel < 0 || sel >= len(rules) {
+		return fmt.Errorf("%d is not a valid choice", sel)
+	}
+	_, err = db.Exec("DELETE FROM pricerules WHERE id = $1", rules[sel].id)
+	return err
+}
+
+func (in *input) pricingMenu(db *DB) error {
+	items := []string{"list price rules and deals [l]", "add time-based price [a]", "add deal [b]", "delete price rule or deal [d]", "main Menu [press enter]"}
+	for _, i := range items {
+		fmt.Fprintf(in.w, "%s\n", i)
+	}
+
+	c, err := in.getString("Choice: ")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c == "l":
+		if err = in.listPricing(db); err != nil {
+			return err
+		}
+	case c == "a":
+		if err = in.addPriceRule(db); err != nil {
+			return err
+		}
+	case c == "b":
+		if err = in.addBundle(db); err != nil {
+			return err
+		}
+	case c == "d":
+		if err = in.deletePricing(db); err != nil {
+			return err
+		}
+	case c == "":
+		return nil
+	default:
+		return fmt.Errorf("%s is not a valid choice", c)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+// fixed returns a price of a price rule.
+func fixed(ct int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: ct, Valid: true}
+}
+
+func TestMoreSpecific(t *testing.T) {
+	all := priceRule{id: 1, window: window{18, 4}}
+	one := priceRule{id: 2, cocktail: "Mojito", window: window{18, 4}}
+	short := priceRule{id: 3, window: window{20, 22}}
+	later := priceRule{id: 4, window: window{18, 4}}
+
+	tests := []struct {
+		r, o priceRule
+		want bool
+	}{
+		{one, all, true},
+		{all, one, false},
+		{one, short, true},
+		{short, all, true},
+		{all, short, false},
+		{later, all, true},
+		{all, later, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.r.moreSpecific(tt.o); got != tt.want {
+			t.Errorf("rule %d more specific than rule %d = %t, want %t", tt.r.id, tt.o.id, got, tt.want)
+		}
+	}
+}
+
+func TestActivePrice(t *testing.T) {
+	f := newFest()
+	f.cocktailprices["Mojito"] = 400
+	f.cocktailprices["Daiquiri"] = 350
+
+	happyHour := priceRule{id: 1, window: window{18, 20}, factor: 0.8}
+	lateNight := priceRule{id: 2, window: window{0, 4}, factor: 1.2}
+	mojitoHour := priceRule{id: 3, cocktail: "Mojito", window: window{19, 20}, price: fixed(300)}
+	free := priceRule{id: 4, window: window{18, 19}, price: fixed(0)}
+	rules := []priceRule{happyHour, lateNight, mojitoHour}
+
+	tests := []struct {
+		rules    []priceRule
+		cocktail string
+		hour     int
+		want     int
+	}{
+		{rules, "Mojito", 22, 400},
+		{rules, "Mojito", 18, 320},
+		{rules, "Daiquiri", 19, 280},
+		// the rule for the Mojito beats the one for all cocktails
+		{rules, "Mojito", 19, 300},
+		{rules, "Daiquiri", 1, 420},
+		// the shorter window beats the longer one
+		{append(rules, free), "Daiquiri", 18, 0},
+		{append(rules, free), "Daiquiri", 19, 280},
+		{nil, "Mojito", 19, 400},
+	}
+
+	for _, tt := range tests {
+		if got := activePrice(f, tt.rules, tt.cocktail, tt.hour); got != tt.want {
+			t.Errorf("activePrice(%s, %d) = %d, want %d", tt.cocktail, tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestBundleSplit(t *testing.T) {
+	f := newFest()
+	f.cocktailprices["Mojito"] = 400
+	f.cocktailprices["Daiquiri"] = 350
+	f.cocktailprices["Punch"] = 0
+
+	tests := []struct {
+		b    bundle
+		want []sale
+	}{
+		{bundle{price: 600, items: map[string]int{"Mojito": 1, "Daiquiri": 1}}, []sale{{"Daiquiri", 280}, {"Mojito", 320}}},
+		{bundle{price: 1000, items: map[string]int{"Mojito": 3}}, []sale{{"Mojito", 333}, {"Mojito", 333}, {"Mojito", 334}}},
+		{bundle{price: 500, items: map[string]int{"Punch": 2}}, []sale{{"Punch", 250}, {"Punch", 250}}},
+		{bundle{price: 500, items: map[string]int{}}, nil},
+	}
+
+	for _, tt := range tests {
+		got := tt.b.split(f)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v split = %v, want %v", tt.b.items, got, tt.want)
+		}
+
+		var sum int
+		for _, s := range got {
+			sum += s.price
+		}
+		if len(got) > 0 && sum != tt.b.price {
+			t.Errorf("%v split adds up to %d, want %d", tt.b.items, sum, tt.b.price)
+		}
+	}
+}
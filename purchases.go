@@ -0,0 +1,347 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+type purchaseItem struct {
+	ingredient string
+	amount     float64
+	price      int
+	received   float64
+	paid       int
+}
+
+type purchase struct {
+	id       int
+	supplier string
+	created  time.Time
+	items    []purchaseItem
+}
+
+func (p purchase) status() string {
+	var open, touched int
+	for _, it := range p.items {
+		if it.received < it.amount {
+			open++
+		}
+		if it.received > 0 {
+			touched++
+		}
+	}
+
+	switch {
+	case open == 0:
+		return "received"
+	case touched == 0:
+		return "open"
+	default:
+		return "partially received"
+	}
+}
+
+func (p purchase) paid() int {
+	var paid int
+	for _, it := range p.items {
+		paid += it.paid
+	}
+	return paid
+}
+
+func (p purchase) price() int {
+	var price int
+	for _, it := range p.items {
+		price += it.price
+	}
+	return price
+}
+
+func (db *DB) getSuppliers() (map[string]string, error) {
+	suppliers := make(map[string]string)
+	rows, err := db.Query("SELECT name, supplier FROM ingredients")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, supplier string
+
+		if err := rows.Scan(&name, &supplier); err != nil {
+			return nil, err
+		}
+		suppliers[name] = supplier
+	}
+	return suppliers, rows.Err()
+}
+
+func (db *DB) getPurchases(date string) ([]purchase, error) {
+	rows, err := db.Query("SELECT id, supplier, created FROM purchases WHERE fest = (SELECT id FROM fests WHERE date = $1) ORDER BY id", date)
+	if err != nil {
+		return nil, err
+	}
+
+	var purchases []purchase
+	for rows.Next() {
+		var p purchase
+
+		if err := rows.Scan(&p.id, &p.supplier, &p.created); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		purchases = append(purchases, p)
+	}
+	rows.Close()
+
+	for i := range purchases {
+		purchases[i].items, err = db.purchaseItems(purchases[i].id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return purchases, nil
+}
+
+func (db *DB) purchaseItems(id int) ([]purchaseItem, error) {
+	rows, err := db.Query("SELECT ingredients.name, purchaseitems.amount, purchaseitems.price, purchaseitems.received, purchaseitems.paid FROM purchaseitems JOIN ingredients ON ingredients.id = purchaseitems.ingredient WHERE purchase = $1 ORDER BY ingredients.name", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []purchaseItem
+	for rows.Next() {
+		var it purchaseItem
+
+		if err := rows.Scan(&it.ingredient, &it.amount, &it.price, &it.received, &it.paid); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// createPurchases turns the shopping list of the current fest into one
+// purchase order per supplier.
+func (db *DB) createPurchases() error {
+	existing, err := db.getPurchases(cfg.Current)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("there already are purchase orders for %s", cfg.Current)
+	}
+
+	list, err := db.genShoppingList()
+	if err != nil {
+		return err
+	}
+	suppliers, err := db.getSuppliers()
+	if err != nil {
+		return err
+	}
+
+	bySupplier := make(map[string][]string)
+	for ing, it := range list {
+		if it.consumable || it.amount <= 0 {
+			continue
+		}
+		bySupplier[suppliers[ing]] = append(bySupplier[suppliers[ing]], ing)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for supplier, ings := range bySupplier {
+		res, err := tx.Exec("INSERT INTO purchases (fest, supplier, created) VALUES ((SELECT id FROM fests WHERE date = $1), $2, $3)", cfg.Current, supplier, time.Now())
+		if err != nil {
+			return err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		for _, ing := range ings {
+			_, err := tx.Exec("INSERT INTO purchaseitems (purchase, ingredient, amount, price) VALUES ($1, (SELECT id FROM ingredients WHERE name = $2), $3, $4)", id, ing, list[ing].amount, int(math.Ceil(list[ing].price)))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) printPurchases(db *DB) error {
+	purchases, err := db.getPurchases(cfg.Current)
+	if err != nil {
+		return err
+	}
+	if len(purchases) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(in.w, "purchase order\tsupplier\tstatus\texpected\tpaid\n")
+	for _, p := range purchases {
+		fmt.Fprintf(in.w, "%d\t%s\t%s\t%.2f €\t%.2f €\n", p.id, p.supplier, p.status(), float64(p.price())/100, float64(p.paid())/100)
+	}
+	fmt.Fprintf(in.w, "\n")
+	return nil
+}
+
+func (in *input) showPurchases(db *DB) error {
+	purchases, err := db.getPurchases(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range purchases {
+		fmt.Fprintf(in.w, "purchase order %d at %s from %s (%s)\n", p.id, p.supplier, p.created.Format(dateFormat), p.status())
+		fmt.Fprintf(in.w, "ingredient\tordered\treceived\texpected\tpaid\n")
+		for _, it := range p.items {
+			fmt.Fprintf(in.w, "%s\t%.2f l\t%.2f l\t%.2f €\t%.2f €\n", it.ingredient, it.amount, it.received, float64(it.price)/100, float64(it.paid)/100)
+		}
+		fmt.Fprintf(in.w, "\n")
+	}
+	return nil
+}
+
+// receivePurchase asks what was actually bought for one purchase order, books
+// it into the stock, perishable goods as lots, and records what was paid for
+// it.
+func (in *input) receivePurchase(db *DB) error {
+	purchases, err := db.getPurchases(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	var open []purchase
+	for _, p := range purchases {
+		if p.status() != "received" {
+			open = append(open, p)
+		}
+	}
+	if len(open) == 0 {
+		return fmt.Errorf("there are no open purchase orders for %s", cfg.Current)
+	}
+
+	for i, p := range open {
+		fmt.Fprintf(in.w, "%d\t%s\t%s\n", i, p.supplier, p.status())
+	}
+
+	sel, err := in.getInt("Which purchase order did you receive? ")
+	if err != nil {
+		return err
+	}
+	if sel < 0 || sel >= len(open) {
+		return fmt.Errorf("%d is not a valid choice", sel)
+	}
+	p := open[sel]
+
+	// the goods received, asked for before writing anything, so the
+	// database is not locked while waiting for input
+	type delivery struct {
+		ingredient string
+		amount     float64
+		paid       int
+		perishable bool
+		bestBefore time.Time
+	}
+	var got []delivery
+
+	var r receipt
+	for _, it := range p.items {
+		if it.received >= it.amount {
+			continue
+		}
+
+		amount, err := in.getFloat("How much %s did you get? [%.2f l open]: ", it.ingredient, it.amount-it.received)
+		if err != nil {
+			return err
+		}
+		if amount == 0 {
+			continue
+		}
+		paid, err := in.getInt("What did you pay for it? [ct]: ")
+		if err != nil {
+			return err
+		}
+
+		d := delivery{ingredient: it.ingredient, amount: amount, paid: paid}
+		choice, err := in.getString("When does it expire? [YYYY-MM-DD, press enter if it keeps]: ")
+		if err != nil {
+			return err
+		}
+		if choice != "" {
+			d.bestBefore, err = time.Parse(dateFormat, choice)
+			if err != nil {
+				return err
+			}
+			d.perishable = true
+		}
+		got = append(got, d)
+
+		r.amount += paid
+		r.items = append(r.items, it.ingredient)
+	}
+
+	if r.amount > 0 {
+		r.payer, err = in.getString("Who paid? ")
+		if err != nil {
+			return err
+		}
+		r.date, err = in.getDate("Date on the receipt [YYYY-MM-DD]: ")
+		if err != nil {
+			return err
+		}
+		r.description = p.supplier
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, d := range got {
+		_, err = tx.Exec("UPDATE purchaseitems SET received = received + $1, paid = paid + $2 WHERE purchase = $3 AND ingredient = (SELECT id FROM ingredients WHERE name = $4)", d.amount, d.paid, p.id, d.ingredient)
+		if err != nil {
+			return err
+		}
+
+		if d.perishable {
+			_, err = tx.Exec("INSERT INTO lots (ingredient, purchased, bestbefore, available) VALUES ((SELECT id FROM ingredients WHERE name = $1), $2, $3, $4)", d.ingredient, time.Now(), d.bestBefore, d.amount)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = bookStock(tx, d.ingredient, d.amount); err != nil {
+			return err
+		}
+	}
+
+	if r.amount > 0 {
+		if err = insertReceipt(tx, r, sql.NullInt64{Int64: int64(p.id), Valid: true}); err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type receipt struct {
+	id          int
+	payer       string
+	amount      int
+	date        time.Time
+	description string
+	reimbursed  bool
+	items       []string
+}
+
+func (db *DB) getReceipts(date string) ([]receipt, error) {
+	rows, err := db.Query("SELECT id, payer, amount, date, description, reimbursed FROM receipts WHERE fest = (SELECT id FROM fests WHERE date = $1) ORDER BY date, id", date)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipts []receipt
+	for rows.Next() {
+		var r receipt
+
+		if err := rows.Scan(&r.id, &r.payer, &r.amount, &r.date, &r.description, &r.reimbursed); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		receipts = append(receipts, r)
+	}
+	rows.Close()
+
+	for i := range receipts {
+		receipts[i].items, err = db.receiptItems(receipts[i].id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return receipts, nil
+}
+
+func (db *DB) receiptItems(id int) ([]string, error) {
+	rows, err := db.Query("SELECT ingredients.name FROM receiptitems JOIN ingredients ON ingredients.id = receiptitems.ingredient WHERE receipt = $1 ORDER BY ingredients.name", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []string
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	return items, rows.Err()
+}
+
+// insertReceipt records a receipt of the current fest and links it to the
+// given ingredients of purchase order purchase, which is NULL if it belongs
+// to none.
+func insertReceipt(tx *sql.Tx, r receipt, purchase sql.NullInt64) error {
+	res, err := tx.Exec("INSERT INTO receipts (fest, payer, amount, date, description) VALUES ((SELECT id FROM fests WHERE date = $1), $2, $3, $4, $5)", cfg.Current, r.payer, r.amount, r.date, r.description)
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, ing := range r.items {
+		_, err := tx.Exec("INSERT INTO receiptitems (receipt, purchase, ingredient) VALUES ($1, $2, (SELECT id FROM ingredients WHERE name = $3))", id, purchase, ing)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (in *input) addReceipt(db *DB) error {
+	var r receipt
+	var err error
+
+	r.payer, err = in.getString("Who paid? ")
+	if err != nil {
+		return err
+	}
+	r.amount, err = in.getInt("How much was paid? [ct]: ")
+	if err != nil {
+		return err
+	}
+	r.date, err = in.getDate("Date on the receipt [YYYY-MM-DD]: ")
+	if err != nil {
+		return err
+	}
+	r.description, err = in.getString("What was bought? ")
+	if err != nil {
+		return err
+	}
+	purchase, err := in.receiptPurchase(db, &r)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = insertReceipt(tx, r, purchase); err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// receiptPurchase asks which purchase order r belongs to and which of its
+// ingredients are on it, or which ingredients if it belongs to none. It
+// returns the id of the purchase order, NULL for none.
+func (in *input) receiptPurchase(db *DB, r *receipt) (sql.NullInt64, error) {
+	var purchase sql.NullInt64
+	purchases, err := db.getPurchases(cfg.Current)
+	if err != nil {
+		return purchase, err
+	}
+
+	var items []string
+	if len(purchases) > 0 {
+		for i, p := range purchases {
+			fmt.Fprintf(in.w, "%d\t%s\t%s\n", i, p.supplier, p.status())
+		}
+		choice, err := in.getString("Which purchase order is it for? [press enter for none]: ")
+		if err != nil {
+			return purchase, err
+		}
+		if choice != "" {
+			sel, err := strconv.Atoi(choice)
+			if err != nil {
+				return purchase, err
+			}
+			if sel < 0 || sel >= len(purchases) {
+				return purchase, fmt.Errorf("%d is not a valid choice", sel)
+			}
+			purchase = sql.NullInt64{Int64: int64(purchases[sel].id), Valid: true}
+			for _, it := range purchases[sel].items {
+				items = append(items, it.ingredient)
+			}
+		}
+	}
+	if !purchase.Valid {
+		items, err = db.getIngredients()
+		if err != nil {
+			return purchase, err
+		}
+	}
+
+	for i, ing := range items {
+		fmt.Fprintf(in.w, "%d\t%s\n", i, ing)
+	}
+	choice, err := in.getString("Which ingredients are on the receipt? [separate with ',', press enter for none]: ")
+	if err != nil {
+		return purchase, err
+	}
+	for _, c := range strings.Split(choice, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		sel, err := strconv.Atoi(c)
+		if err != nil {
+			return purchase, err
+		}
+		if sel < 0 || sel >= len(items) {
+			return purchase, fmt.Errorf("%d is not a valid choice", sel)
+		}
+		r.items = append(r.items, items[sel])
+	}
+	return purchase, nil
+}
+
+func (in *input) listReceipts(db *DB) ([]receipt, error) {
+	receipts, err := db.getReceipts(cfg.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(in.w, "number\tdate\tpayer\tamount\treimbursed\titems\n")
+	for i, r := range receipts {
+		reimbursed := "no"
+		if r.reimbursed {
+			reimbursed = "yes"
+		}
+
+		items := r.description
+		if len(r.items) > 0 {
+			items = strings.Join(r.items, ", ")
+		}
+		fmt.Fprintf(in.w, "%d\t%s\t%s\t%.2f €\t%s\t%s\n", i, r.date.Format(dateFormat), r.payer, float64(r.amount)/100, reimbursed, items)
+	}
+	return receipts, nil
+}
+
+func (in *input) reimburse(db *DB) error {
+	receipts, err := in.listReceipts(db)
+	if err != nil {
+		return err
+	}
+
+	choice, err := in.getString("Which receipts were reimbursed? [separate with ',']: ")
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range strings.Split(choice, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(c))
+		if err != nil {
+			return err
+		}
+		if id < 0 || id >= len(receipts) {
+			return fmt.Errorf("%d is not a valid choice", id)
+		}
+
+		_, err = tx.Exec("UPDATE receipts SET reimbursed = 1 WHERE id = $1", receipts[id].id)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) reimbursementSummary(db *DB) error {
+	receipts, err := db.getReceipts(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	paid := make(map[string]int)
+	open := make(map[string]int)
+	var payers []string
+	for _, r := range receipts {
+		if _, ok := paid[r.payer]; !ok {
+			payers = append(payers, r.payer)
+		}
+		paid[r.payer] += r.amount
+		if !r.reimbursed {
+			open[r.payer] += r.amount
+		}
+	}
+	sort.Strings(payers)
+
+	fmt.Fprintf(in.w, "person\tpaid\tstill to reimburse\n")
+	var total int
+	for _, p := range payers {
+		fmt.Fprintf(in.w, "%s\t%.2f €\t%.2f €\n", p, float64(paid[p])/100, float64(open[p])/100)
+		total += open[p]
+	}
+	fmt.Fprintf(in.w, "Still to reimburse for %s: %.2f €\n", cfg.Current, float64(total)/100)
+	return nil
+}
+
+func (in *input) receiptMenu(db *DB) error {
+	items := []string{"add receipt [a]", "list receipts [l]", "mark receipts as reimbursed [m]", "reimbursement summary [s]", "main Menu [press enter]"}
+	for _, i := range items {
+		fmt.Fprintf(in.w, "%s\n", i)
+	}
+
+	c, err := in.getString("Choice: ")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c == "a":
+		if err = in.addReceipt(db); err != nil {
+			return err
+		}
+	case c == "l":
+		if _, err = in.listReceipts(db); err != nil {
+			return err
+		}
+	case c == "m":
+		if err = in.reimburse(db); err != nil {
+			return err
+		}
+	case c == "s":
+		if err = in.reimbursementSummary(db); err != nil {
+			return err
+		}
+	case c == "":
+		return nil
+	default:
+		return fmt.Errorf("%s is not a valid choice", c)
+	}
+
+	return nil
+}
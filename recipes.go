@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var methods = []string{"shaken", "stirred", "built", "blended"}
+
+type recipeStep struct {
+	ingredient string
+	amount     float64
+	// inherited is set if the step comes from the base cocktail
+	inherited bool
+}
+
+// cocktailSteps returns the ingredients of a cocktail in the order they are
+// poured. Variants are poured like their base cocktail, their own
+// ingredients afterwards. An ingredient poured several times is one step
+// with the amounts added up, like in cocktailIngredients.
+func (db *DB) cocktailSteps(cocktail string) ([]recipeStep, error) {
+	var steps []recipeStep
+	// add pours s, adding it to an earlier step of the same ingredient
+	add := func(s recipeStep) {
+		for i := range steps {
+			if steps[i].ingredient == s.ingredient {
+				steps[i].amount += s.amount
+				return
+			}
+		}
+		steps = append(steps, s)
+	}
+
+	base, scale, err := db.cocktailBase(cocktail)
+	if err != nil {
+		return nil, err
+	}
+	if base != "" {
+		baseSteps, err := db.cocktailSteps(base)
+		if err != nil {
+			return nil, err
+		}
+		subs, err := db.substitutions(cocktail)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range baseSteps {
+			s.ingredient, s.amount = subs.apply(s.ingredient, s.amount*scale)
+			s.inherited = true
+			if s.ingredient != "" {
+				add(s)
+			}
+		}
+	}
+
+	rows, err := db.Query("SELECT ingredients.name, cocktailingredients.amount FROM cocktailingredients JOIN ingredients ON ingredients.id = cocktailingredients.ingredient WHERE cocktail = (SELECT id FROM cocktails WHERE name = $1) ORDER BY cocktailingredients.position, ingredients.name", cocktail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s recipeStep
+
+		if err := rows.Scan(&s.ingredient, &s.amount); err != nil {
+			return nil, err
+		}
+		add(s)
+	}
+	return steps, rows.Err()
+}
+
+func (db *DB) getBatched() (map[string]bool, error) {
+	batched := make(map[string]bool)
+	rows, err := db.Query("SELECT name, batched FROM ingredients")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var b bool
+
+		if err := rows.Scan(&name, &b); err != nil {
+			return nil, err
+		}
+		batched[name] = b
+	}
+	return batched, rows.Err()
+}
+
+// genPrepSheet returns how many liters of each batched ingredient have to be
+// mixed and how many fruits have to be cut for the cocktails planned in f.
+func (db *DB) genPrepSheet(f fest) (batches map[string]float64, fruits map[string]int, err error) {
+	batched, err := db.getBatched()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batches = make(map[string]float64)
+	for _, name := range f.cocktails {
+		ingreds, err := db.cocktailIngredients(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for ing, amount := range ingreds {
+			if batched[ing] {
+				batches[ing] += amount * float64(f.cocktailamounts[name])
+			}
+		}
+	}
+
+	consumables, err := db.getConsumables()
+	if err != nil {
+		return nil, nil, err
+	}
+	needs, err := db.consumableNeeds(f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fruits = make(map[string]int)
+	for _, c := range consumables {
+		if c.cutFrom == "" || c.perFruit <= 0 {
+			continue
+		}
+		fruits[c.cutFrom] += int(math.Ceil(needs[c.name] / c.perFruit))
+	}
+
+	return batches, fruits, nil
+}
+
+// recipeCard returns the recipe card of c in the markup of pdf.layout.
+func recipeCard(c cocktail, steps []recipeStep) string {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "# %s\n", c.name)
+	if c.method != "" {
+		fmt.Fprintf(&b, "%s\n", c.method)
+	}
+	if c.glass != "" {
+		fmt.Fprintf(&b, "Glass: %s\n", c.glass)
+	}
+	fmt.Fprintf(&b, "\n")
+	for i, s := range steps {
+		fmt.Fprintf(&b, "## %d. %.1f cl %s\n", i+1, s.amount*100, s.ingredient)
+	}
+	if c.garnish != "" {
+		fmt.Fprintf(&b, "\nGarnish: %s\n", c.garnish)
+	}
+	if c.instructions != "" {
+		fmt.Fprintf(&b, "\n%s\n", c.instructions)
+	}
+	return b.String()
+}
+
+func (in *input) printRecipes(db *DB) error {
+	f, err := db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+	cocktails, err := db.getCocktails()
+	if err != nil {
+		return err
+	}
+
+	var text bytes.Buffer
+	for _, c := range cocktails {
+		if _, ok := f.cocktailamounts[c.name]; !ok {
+			continue
+		}
+
+		steps, err := db.cocktailSteps(c.name)
+		if err != nil {
+			return err
+		}
+		text.WriteString(recipeCard(c, steps))
+		text.WriteString("\f\n")
+	}
+
+	batches, fruits, err := db.genPrepSheet(f)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range batches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(&text, "# Prep sheet %s\n", cfg.Current)
+	fmt.Fprintf(in.w, "to batch\tamount\n")
+	for _, name := range names {
+		fmt.Fprintf(&text, "%s: %.2f l\n", name, batches[name])
+		fmt.Fprintf(in.w, "%s\t%.2f l\n", name, batches[name])
+	}
+
+	names = names[:0]
+	for name := range fruits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(&text, "\n")
+	fmt.Fprintf(in.w, "to cut\tfruits\n")
+	for _, name := range names {
+		fmt.Fprintf(&text, "cut %d %s\n", fruits[name], name)
+		fmt.Fprintf(in.w, "%s\t%d\n", name, fruits[name])
+	}
+
+	file, err := in.getString("File name for the recipe cards [without extension]: ")
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		file = "recipes"
+	}
+
+	var doc pdf
+	doc.layout(text.String())
+
+	out, err := os.Create(file + ".pdf")
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err = doc.write(out); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "Recipe cards written to %s.pdf\n", file)
+	return nil
+}
+
+// keep returns s, or old if s is empty.
+func keep(s, old string) string {
+	if s == "" {
+		return old
+	}
+	return s
+}
+
+func (in *input) alterRecipe(c cocktail, db *DB) error {
+	name := c.name
+	fmt.Fprintf(in.w, "Press enter to keep the current value.\n")
+
+	method, err := in.getString("How is %s prepared? [%s; currently %s]: ", name, strings.Join(methods, ", "), c.method)
+	if err != nil {
+		return err
+	}
+	method = keep(method, c.method)
+	valid := method == ""
+	for _, m := range methods {
+		valid = valid || m == method
+	}
+	if !valid {
+		return fmt.Errorf("%s is not a valid preparation method", method)
+	}
+
+	glass, err := in.getString("Which glass is it served in? [currently %s]: ", c.glass)
+	if err != nil {
+		return err
+	}
+	garnish, err := in.getString("How is it garnished? [currently %s]: ", c.garnish)
+	if err != nil {
+		return err
+	}
+	instructions, err := in.getString("Instructions for the bartender [currently %s]: ", c.instructions)
+	if err != nil {
+		return err
+	}
+	photo, err := in.getString("Path to a photo [currently %s]: ", c.photo)
+	if err != nil {
+		return err
+	}
+
+	steps, err := db.cocktailSteps(name)
+	if err != nil {
+		return err
+	}
+	for i, s := range steps {
+		fmt.Fprintf(in.w, "%d %s\t%.1f cl\n", i, s.ingredient, s.amount*100)
+	}
+
+	order, err := in.getString("Order of pouring [separate with ',', press enter to keep]: ")
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE cocktails SET method = $1, glass = $2, garnish = $3, instructions = $4, photo = $5 WHERE name = $6", method, keep(glass, c.glass), keep(garnish, c.garnish), keep(instructions, c.instructions), keep(photo, c.photo), name)
+	if err != nil {
+		return err
+	}
+
+	if order != "" {
+		for pos, o := range strings.Split(order, ",") {
+			i, err := strconv.Atoi(strings.TrimSpace(o))
+			if err != nil {
+				return err
+			}
+			if i < 0 || i >= len(steps) {
+				return fmt.Errorf("%d is not a valid choice", i)
+			}
+			if steps[i].inherited {
+				return fmt.Errorf("%s is poured like in the base cocktail, change the order there", steps[i].ingredient)
+			}
+
+			_, err = tx.Exec("UPDATE cocktailingredients SET position = $1 WHERE cocktail = (SELECT id FROM cocktails WHERE name = $2) AND ingredient = (SELECT id FROM ingredients WHERE name = $3)", pos, name, steps[i].ingredient)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) toggleBatched(db *DB) error {
+	batched, err := db.getBatched()
+	if err != nil {
+		return err
+	}
+
+	var numberedInv []string
+	for item := range batched {
+		numberedInv = append(numberedInv, item)
+	}
+	sort.Strings(numberedInv)
+
+	fmt.Fprintf(in.w, "   ingredient\tmixed in advance\n")
+	for i, item := range numberedInv {
+		fmt.Fprintf(in.w, "%d: %s\t%t\n", i, item, batched[item])
+	}
+
+	update, err := in.getInt("Which item do you want to toggle? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(numberedInv) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+
+	_, err = db.Exec("UPDATE ingredients SET batched = $1 WHERE name = $2", !batched[numberedInv[update]], numberedInv[update])
+	if err != nil {
+		return err
+	}
+	return nil
+}
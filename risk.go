@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// simulations is the number of fests simulated to estimate risks.
+	simulations = 2000
+	// turnoutUncertainty is the relative standard deviation of the number
+	// of guests that actually come.
+	turnoutUncertainty = 0.15
+	// seed makes the simulation, and thus the shopping list, reproducible.
+	seed = 1
+)
+
+// demand holds everything needed to turn amounts of cocktails into the
+// amounts of ingredients that have to be bought for them.
+type demand struct {
+	cocktails []cocktail
+	stock     map[string]float64
+	parts     parts
+	eq        equivalents
+	prices    map[string]int
+}
+
+func (db *DB) getDemand() (demand, error) {
+	var d demand
+
+	cocktails, err := db.getCocktails()
+	if err != nil {
+		return demand{}, err
+	}
+	day, err := db.festDay(cfg.Current)
+	if err != nil {
+		return demand{}, err
+	}
+	d.stock, err = db.usableStock(day)
+	if err != nil {
+		return demand{}, err
+	}
+	d.parts, err = db.getParts()
+	if err != nil {
+		return demand{}, err
+	}
+	d.eq, err = db.getEquivalents()
+	if err != nil {
+		return demand{}, err
+	}
+	d.prices, err = db.getIngredientPrices()
+	if err != nil {
+		return demand{}, err
+	}
+	d.cocktails = cocktails
+	return d, nil
+}
+
+// buy returns the liters of each ingredient that have to be bought to make
+// amounts of cocktails.
+func (d demand) buy(amounts map[string]float64) (map[string]float64, error) {
+	stock := make(map[string]float64)
+	for ing, a := range d.stock {
+		stock[ing] = a
+	}
+	needs := make(map[string]float64)
+
+	for _, c := range d.cocktails {
+		for z, m := range c.ingredients {
+			err := d.parts.expand(z, amounts[c.name]*m, stock, needs, make(map[string]bool))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for ing := range d.parts {
+		delete(stock, ing)
+	}
+
+	return d.eq.toBuy(needs, stock, d.prices), nil
+}
+
+// risk describes the outcome of simulating a fest for one ingredient.
+type risk struct {
+	// planned is the amount to buy for the planned cocktails
+	planned float64
+	// stockOut is the probability of running out when buying planned
+	stockOut float64
+	// quantity is the amount to buy to not run out with cfg.ServiceLevel,
+	// planned if it is not set
+	quantity float64
+	// leftover is the expected value of what is left when buying quantity
+	leftover float64
+}
+
+// simulate simulates f many times. Every time, the number of guests and how
+// many of each cocktail they choose vary around the planned amounts, the
+// latter as much as the forecast of the cocktail does.
+func (db *DB) simulate(d demand, f fest) (map[string]risk, error) {
+	forecasts, err := db.forecastMenu(f.cocktails, f.guests())
+	if err != nil {
+		return nil, err
+	}
+	cvs := make(map[string]float64)
+	planned := make(map[string]float64)
+	for _, c := range f.cocktails {
+		fc := forecasts[c]
+		cvs[c] = defaultUncertainty
+		if fc.expected > 0 {
+			cvs[c] = (fc.high - fc.expected) / fc.expected
+		}
+		planned[c] = float64(f.cocktailamounts[c])
+	}
+
+	plannedBuy, err := d.buy(planned)
+	if err != nil {
+		return nil, err
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	samples := make(map[string][]float64)
+	for i := 0; i < simulations; i++ {
+		turnout := math.Max(0, 1+r.NormFloat64()*turnoutUncertainty)
+
+		amounts := make(map[string]float64)
+		for _, c := range f.cocktails {
+			amounts[c] = planned[c] * turnout * math.Max(0, 1+r.NormFloat64()*cvs[c])
+		}
+
+		buy, err := d.buy(amounts)
+		if err != nil {
+			return nil, err
+		}
+		for ing := range plannedBuy {
+			samples[ing] = append(samples[ing], buy[ing])
+		}
+	}
+
+	risks := make(map[string]risk)
+	for ing, s := range samples {
+		sort.Float64s(s)
+
+		rk := risk{planned: plannedBuy[ing]}
+		i := int(math.Ceil(cfg.ServiceLevel*float64(len(s)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(s) {
+			i = len(s) - 1
+		}
+		rk.quantity = s[i]
+		if cfg.ServiceLevel <= 0 {
+			rk.quantity = rk.planned
+		}
+
+		for _, need := range s {
+			if need > rk.planned+1e-9 {
+				rk.stockOut++
+			}
+			rk.leftover += math.Max(0, rk.quantity-need) * float64(d.prices[ing])
+		}
+		rk.stockOut /= float64(len(s))
+		rk.leftover /= float64(len(s))
+		risks[ing] = rk
+	}
+	return risks, nil
+}
+
+func (in *input) printRisks(db *DB) error {
+	d, err := db.getDemand()
+	if err != nil {
+		return err
+	}
+	f, err := db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+	risks, err := db.simulate(d, f)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for ing := range risks {
+		names = append(names, ing)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(in.w, "Simulated %d fests, buying for a service level of %.0f %%:\n", simulations, cfg.ServiceLevel*100)
+	fmt.Fprintf(in.w, "ingredient\tplanned\tstock-out\tto buy\texpected leftover\n")
+	var leftover float64
+	for _, ing := range names {
+		rk := risks[ing]
+		fmt.Fprintf(in.w, "%s\t%.2f l\t%.0f %%\t%.2f l\t%.2f €\n", ing, rk.planned, rk.stockOut*100, rk.quantity, rk.leftover/100)
+		leftover += rk.leftover
+	}
+	fmt.Fprintf(in.w, "total\t\t\t\t%.2f €\n", leftover/100)
+	return nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// rule configures a plan check. Which of the bounds are used depends on the
+// check. A rule with Error set prevents changes to a fest that violate it,
+// otherwise violations are only warned about. A rule with Off set is not
+// checked at all.
+type rule struct {
+	Min, Max float64
+	Error    bool
+	Off      bool
+}
+
+// defaultRules are used for the checks not configured in cfg.Rules.
+var defaultRules = map[string]rule{
+	"PerGuest":           {Min: 1.6, Max: 2.4},
+	"AlcoholFree":        {Min: 0.2},
+	"AlcoholFreeCheaper": {Error: true},
+	"Tokens":             {Error: true},
+}
+
+// planCheck returns how f violates r or an empty string if it does not.
+type planCheck func(db *DB, f fest, r rule) (string, error)
+
+var planChecks = map[string]planCheck{
+	"PerGuest":           checkPerGuest,
+	"MenuSize":           checkMenuSize,
+	"Share":              checkShare,
+	"Budget":             checkBudget,
+	"Margin":             checkMargin,
+	"AlcoholFree":        checkAlcoholFreeShare,
+	"AlcoholFreeCheaper": checkAlcoholFreePrices,
+	"Tokens":             checkTokens,
+}
+
+// rules returns the rules to check, cfg.Rules overriding defaultRules.
+func rules() map[string]rule {
+	rs := make(map[string]rule)
+	for name, r := range defaultRules {
+		rs[name] = r
+	}
+	for name, r := range cfg.Rules {
+		rs[name] = r
+	}
+	return rs
+}
+
+type violation struct {
+	rule  string
+	msg   string
+	error bool
+}
+
+// checkPlan returns all rules f violates, sorted by rule name.
+func (db *DB) checkPlan(f fest) ([]violation, error) {
+	rs := rules()
+	var names []string
+	for name := range rs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []violation
+	for _, name := range names {
+		check, ok := planChecks[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a known rule", name)
+		}
+		if rs[name].Off {
+			continue
+		}
+		msg, err := check(db, f, rs[name])
+		if err != nil {
+			return nil, err
+		}
+		if msg != "" {
+			violations = append(violations, violation{name, msg, rs[name].Error})
+		}
+	}
+	return violations, nil
+}
+
+// checkChange returns an error if planned violates a rule with Error set
+// that f, the fest before the change, does not violate already.
+func (db *DB) checkChange(f, planned fest) error {
+	before, err := db.checkPlan(f)
+	if err != nil {
+		return err
+	}
+	after, err := db.checkPlan(planned)
+	if err != nil {
+		return err
+	}
+
+	violated := make(map[string]bool)
+	for _, v := range before {
+		violated[v.rule] = true
+	}
+	for _, v := range after {
+		if v.error && !violated[v.rule] {
+			return fmt.Errorf("%s", v.msg)
+		}
+	}
+	return nil
+}
+
+// with returns a copy of f with amount of cocktail planned for price.
+func (f fest) with(cocktail string, amount, price int) fest {
+	planned := newFest()
+	planned.date = f.date
+	planned.awaited = f.awaited
+	planned.cocktails = append(planned.cocktails, f.cocktails...)
+	for c, p := range f.cocktailprices {
+		planned.cocktailprices[c] = p
+		planned.cocktailamounts[c] = f.cocktailamounts[c]
+	}
+	if _, ok := planned.cocktailprices[cocktail]; !ok {
+		planned.cocktails = append(planned.cocktails, cocktail)
+	}
+	planned.cocktailprices[cocktail] = price
+	planned.cocktailamounts[cocktail] = amount
+	return planned
+}
+
+// without returns a copy of f without cocktail.
+func (f fest) without(cocktail string) fest {
+	planned := newFest()
+	planned.date = f.date
+	planned.awaited = f.awaited
+	for _, c := range f.cocktails {
+		if c == cocktail {
+			continue
+		}
+		planned.cocktails = append(planned.cocktails, c)
+		planned.cocktailprices[c] = f.cocktailprices[c]
+		planned.cocktailamounts[c] = f.cocktailamounts[c]
+	}
+	return planned
+}
+
+// printViolations prints a warning or an error for every rule f violates and
+// returns whether there was any.
+func (in *input) printViolations(db *DB, f fest) (bool, error) {
+	violations, err := db.checkPlan(f)
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range violations {
+		if v.error {
+			fmt.Fprintf(in.w, "Error: %s.\n", v.msg)
+		} else {
+			fmt.Fprintf(in.w, "Warning: %s.\n", v.msg)
+		}
+	}
+	return len(violations) > 0, nil
+}
+
+func totalPlanned(f fest) int {
+	var n int
+	for _, c := range f.cocktails {
+		n += f.cocktailamounts[c]
+	}
+	return n
+}
+
+// planCost returns the cost of the shopping list and the expected revenue of
+// f in cents at the prices active over the night. The shopping list is
+// without the safety stock of cfg.ServiceLevel, as simulating it on every
+// change of the plan is too slow.
+func (db *DB) planCost(f fest) (cost, revenue float64, err error) {
+	list, err := db.shoppingListFor(f, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, it := range list {
+		cost += it.price
+	}
+	revenue, err = db.expectedRevenue(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cost, revenue, nil
+}
+
+// checkPerGuest checks that between Min and Max cocktails per guest are
+// planned.
+func checkPerGuest(db *DB, f fest, r rule) (string, error) {
+	if f.guests() <= 0 {
+		return "", nil
+	}
+	ratio := float64(totalPlanned(f)) / float64(f.guests())
+	switch {
+	case r.Max <= 0 && ratio < r.Min:
+		return fmt.Sprintf("%.2f cocktails/guest are planned, there should be at least %.1f", ratio, r.Min), nil
+	case r.Max > 0 && (ratio < r.Min || ratio > r.Max):
+		return fmt.Sprintf("%.2f cocktails/guest are planned, there should be between %.1f and %.1f", ratio, r.Min, r.Max), nil
+	}
+	return "", nil
+}
+
+// checkMenuSize checks that at least Min and, if set, at most Max cocktails
+// are on the menu.
+func checkMenuSize(db *DB, f fest, r rule) (string, error) {
+	n := float64(len(f.cocktails))
+	if n < r.Min {
+		return fmt.Sprintf("only %d cocktails are on the menu, there should be at least %.0f", len(f.cocktails), r.Min), nil
+	}
+	if r.Max > 0 && n > r.Max {
+		return fmt.Sprintf("%d cocktails are on the menu, there should be at most %.0f", len(f.cocktails), r.Max), nil
+	}
+	return "", nil
+}
+
+// checkShare checks that no cocktail makes up more than Max of the planned
+// cocktails.
+func checkShare(db *DB, f fest, r rule) (string, error) {
+	total := totalPlanned(f)
+	if total == 0 || r.Max <= 0 {
+		return "", nil
+	}
+	for _, c := range f.cocktails {
+		share := float64(f.cocktailamounts[c]) / float64(total)
+		if share > r.Max {
+			return fmt.Sprintf("%s makes up %.0f %% of the planned cocktails, it should be at most %.0f %%", c, share*100, r.Max*100), nil
+		}
+	}
+	return "", nil
+}
+
+// checkBudget checks that the shopping list costs at most Max €.
+func checkBudget(db *DB, f fest, r rule) (string, error) {
+	if r.Max <= 0 {
+		return "", nil
+	}
+	cost, _, err := db.planCost(f)
+	if err != nil {
+		return "", err
+	}
+	if cost/100 > r.Max {
+		return fmt.Sprintf("the shopping list costs %.2f € without safety stock, the budget is %.2f €", cost/100, r.Max), nil
+	}
+	return "", nil
+}
+
+// checkMargin checks that at least Min of the expected revenue is profit.
+func checkMargin(db *DB, f fest, r rule) (string, error) {
+	cost, revenue, err := db.planCost(f)
+	if err != nil {
+		return "", err
+	}
+	if revenue <= 0 {
+		return "", nil
+	}
+	margin := (revenue - cost) / revenue
+	if margin < r.Min {
+		return fmt.Sprintf("the margin is %.0f %%, it should be at least %.0f %%", margin*100, r.Min*100), nil
+	}
+	return "", nil
+}
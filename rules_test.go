@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPerGuest(t *testing.T) {
+	defer func(awaited int) { cfg.Awaited = awaited }(cfg.Awaited)
+	cfg.Awaited = 100
+
+	plan := func(amount, awaited int) fest {
+		f := newFest()
+		f.cocktails = []string{"Mojito"}
+		f.cocktailamounts["Mojito"] = amount
+		f.awaited = awaited
+		return f
+	}
+
+	tests := []struct {
+		f    fest
+		r    rule
+		want string
+	}{
+		{plan(200, 0), rule{Min: 1.6, Max: 2.4}, ""},
+		{plan(100, 0), rule{Min: 1.6, Max: 2.4}, "between 1.6 and 2.4"},
+		{plan(300, 0), rule{Min: 1.6, Max: 2.4}, "between 1.6 and 2.4"},
+		{plan(300, 0), rule{Min: 1.6}, ""},
+		{plan(100, 0), rule{Min: 1.6}, "at least 1.6"},
+		// the guests of a scenario count instead of cfg.Awaited
+		{plan(200, 50), rule{Min: 1.6, Max: 2.4}, "4.00 cocktails/guest"},
+		{plan(100, 50), rule{Min: 1.6, Max: 2.4}, ""},
+	}
+
+	for _, tt := range tests {
+		got, err := checkPerGuest(nil, tt.f, tt.r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("checkPerGuest(%d for %d guests, %+v) = %q, want %q", totalPlanned(tt.f), tt.f.guests(), tt.r, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// scenario is a named draft of the plan of the current fest.
+type scenario struct {
+	id      int
+	name    string
+	awaited int
+	plan    fest
+}
+
+func (db *DB) getScenarios() ([]scenario, error) {
+	rows, err := db.Query("SELECT id, name, awaited FROM scenarios WHERE fest = (SELECT id FROM fests WHERE date = $1) ORDER BY name", cfg.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenarios []scenario
+	for rows.Next() {
+		var s scenario
+
+		if err := rows.Scan(&s.id, &s.name, &s.awaited); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		scenarios = append(scenarios, s)
+	}
+	rows.Close()
+
+	for i := range scenarios {
+		scenarios[i].plan, err = db.scenarioPlan(scenarios[i].id)
+		if err != nil {
+			return nil, err
+		}
+		scenarios[i].plan.awaited = scenarios[i].awaited
+	}
+	return scenarios, nil
+}
+
+func (db *DB) scenarioPlan(id int) (fest, error) {
+	f := newFest()
+	f.date = cfg.Current
+
+	rows, err := db.Query("SELECT cocktails.name, scenariococktails.amount, scenariococktails.price FROM scenariococktails JOIN cocktails ON cocktails.id = scenariococktails.cocktails WHERE scenario = $1 ORDER BY cocktails.name", id)
+	if err != nil {
+		return newFest(), err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c string
+		var a, p int
+
+		if err := rows.Scan(&c, &a, &p); err != nil {
+			return newFest(), err
+		}
+		f.cocktails = append(f.cocktails, c)
+		f.cocktailamounts[c] = a
+		f.cocktailprices[c] = p
+	}
+	return f, rows.Err()
+}
+
+// createScenario creates a scenario starting from the live plan of the
+// current fest, its amounts scaled to the guests awaited in the scenario.
+func (in *input) createScenario(db *DB) error {
+	name, err := in.getString("Name of the scenario: ")
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("a scenario needs a name")
+	}
+
+	awaited := cfg.Awaited
+	choice, err := in.getString("How many guests are awaited? [press enter for %d]: ", cfg.Awaited)
+	if err != nil {
+		return err
+	}
+	if choice != "" {
+		awaited, err = strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+		if awaited <= 0 {
+			return fmt.Errorf("%d is not a valid number of guests", awaited)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO scenarios (fest, name, awaited) VALUES ((SELECT id FROM fests WHERE date = $1), $2, $3)", cfg.Current, name, awaited)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO scenariococktails (scenario, cocktails, price, amount) SELECT $1, cocktails, price, CAST(ROUND(amount * $2) AS INTEGER) FROM festcocktails WHERE fest = (SELECT id FROM fests WHERE date = $3)", id, turnout(awaited, cfg.Awaited), cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// turnout returns the factor the cocktails planned for before guests are
+// scaled with for awaited guests.
+func turnout(awaited, before int) float64 {
+	if before <= 0 {
+		return 1
+	}
+	return float64(awaited) / float64(before)
+}
+
+func (in *input) chooseScenario(db *DB) (scenario, error) {
+	scenarios, err := db.getScenarios()
+	if err != nil {
+		return scenario{}, err
+	}
+	for i, s := range scenarios {
+		fmt.Fprintf(in.w, "%d\t%s\t%d guests\n", i, s.name, s.awaited)
+	}
+
+	sel, err := in.getInt("Which scenario? ")
+	if err != nil {
+		return scenario{}, err
+	}
+	if sel < 0 || sel >= len(scenarios) {
+		return scenario{}, fmt.Errorf("%d is not a valid choice", sel)
+	}
+	return scenarios[sel], nil
+}
+
+func (in *input) alterScenario(db *DB) error {
+	s, err := in.chooseScenario(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "%s\t%s\t%s\n", "cocktail", "planned", "price")
+	for i, c := range s.plan.cocktails {
+		fmt.Fprintf(in.w, "%d %s\t%d\t%.2f €\n", i, c, s.plan.cocktailamounts[c], float64(s.plan.cocktailprices[c])/100.0)
+	}
+
+	choice, err := in.getString("Do you want to [a]dd, [c]hange or [d]eselect a cocktail or change the [g]uests? ")
+	if err != nil {
+		return err
+	}
+
+	switch choice {
+	case "g":
+		awaited, err := in.getInt("How many guests are awaited? ")
+		if err != nil {
+			return err
+		}
+		if awaited <= 0 {
+			return fmt.Errorf("%d is not a valid number of guests", awaited)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		_, err = tx.Exec("UPDATE scenarios SET awaited = $1 WHERE id = $2", awaited, s.id)
+		if err != nil {
+			return err
+		}
+		// more or less guests drink more or less cocktails
+		_, err = tx.Exec("UPDATE scenariococktails SET amount = CAST(ROUND(amount * $1) AS INTEGER) WHERE scenario = $2", turnout(awaited, s.awaited), s.id)
+		if err != nil {
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		fmt.Fprintf(in.w, "The planned cocktails were scaled to %d guests.\n", awaited)
+		return nil
+	case "d":
+		sel, err := in.getInt("Which cocktail do you want to deselect? ")
+		if err != nil {
+			return err
+		}
+		if sel < 0 || sel >= len(s.plan.cocktails) {
+			return fmt.Errorf("%d is not a valid choice", sel)
+		}
+		_, err = db.Exec("DELETE FROM scenariococktails WHERE scenario = $1 AND cocktails = (SELECT id FROM cocktails WHERE name = $2)", s.id, s.plan.cocktails[sel])
+		return err
+	case "c":
+		sel, err := in.getInt("Which cocktail do you want to change? ")
+		if err != nil {
+			return err
+		}
+		if sel < 0 || sel >= len(s.plan.cocktails) {
+			return fmt.Errorf("%d is not a valid choice", sel)
+		}
+		return in.scenarioCocktail(db, s, s.plan.cocktails[sel])
+	case "a":
+		cocktails, err := db.getCocktails()
+		if err != nil {
+			return err
+		}
+		match, err := in.searchCocktails(cocktails, db)
+		if err != nil {
+			return err
+		}
+		for i, c := range cocktails {
+			if _, ok := s.plan.cocktailamounts[c.name]; !ok && match[c.name] {
+				fmt.Fprintf(in.w, "%d\t%s\n", i, c.name)
+			}
+		}
+
+		choice, err := in.getString("Separate choice with ',': ")
+		if err != nil {
+			return err
+		}
+		for _, c := range strings.Split(choice, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(c))
+			if err != nil {
+				return err
+			}
+			if id < 0 || id >= len(cocktails) {
+				return fmt.Errorf("%d is not a valid choice", id)
+			}
+			s.plan.cocktails = append(s.plan.cocktails, cocktails[id].name)
+			if err := in.scenarioCocktail(db, s, cocktails[id].name); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s is not a valid choice", choice)
+	}
+}
+
+// scenarioCocktail asks for the planned amount and price of cocktail and
+// stores them in s.
+func (in *input) scenarioCocktail(db *DB, s scenario, cocktail string) error {
+	forecasts, err := db.forecastMenu(s.plan.cocktails, s.awaited)
+	if err != nil {
+		return err
+	}
+	fc := forecasts[cocktail]
+
+	choice, err := in.getString("How many %s are you planning for? [forecast %s, press enter to use it]: ", cocktail, fc)
+	if err != nil {
+		return err
+	}
+	amount := int(math.Round(fc.expected))
+	if choice != "" {
+		amount, err = strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+	}
+	price, err := in.getInt("What's the price for a %s [ct]? ", cocktail)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("INSERT OR REPLACE INTO scenariococktails (scenario, cocktails, price, amount) VALUES ($1, (SELECT id FROM cocktails WHERE name = $2), $3, $4)", s.id, cocktail, price, amount)
+	return err
+}
+
+func (db *DB) deleteScenario(s scenario) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM scenariococktails WHERE scenario = $1", s.id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM scenarios WHERE id = $1", s.id)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// compareScenarios prints the live plan and all scenarios of the current
+// fest side by side.
+func (in *input) compareScenarios(db *DB) error {
+	live, err := db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+	scenarios, err := db.getScenarios()
+	if err != nil {
+		return err
+	}
+	scenarios = append([]scenario{{name: "live", awaited: cfg.Awaited, plan: live}}, scenarios...)
+
+	rows := []string{"cocktails on menu", "cocktails planned", "guests", "cocktails/guest", "shopping cost", "expected revenue", "profit"}
+	cols := make([][]string, len(scenarios))
+	for i, s := range scenarios {
+		list, err := db.shoppingListFor(s.plan, true)
+		if err != nil {
+			return err
+		}
+		var cost float64
+		for _, it := range list {
+			cost += it.price
+		}
+
+		revenue, err := db.expectedRevenue(s.plan)
+		if err != nil {
+			return err
+		}
+		planned := totalPlanned(s.plan)
+		var ratio float64
+		if s.awaited > 0 {
+			ratio = float64(planned) / float64(s.awaited)
+		}
+
+		cols[i] = []string{
+			strconv.Itoa(len(s.plan.cocktails)),
+			strconv.Itoa(planned),
+			strconv.Itoa(s.awaited),
+			fmt.Sprintf("%.2f", ratio),
+			fmt.Sprintf("%.2f €", cost/100),
+			fmt.Sprintf("%.2f €", revenue/100),
+			fmt.Sprintf("%.2f €", (revenue-cost)/100),
+		}
+	}
+
+	fmt.Fprintf(in.w, "scenario")
+	for _, s := range scenarios {
+		fmt.Fprintf(in.w, "\t%s", s.name)
+	}
+	fmt.Fprintf(in.w, "\n")
+	for r, name := range rows {
+		fmt.Fprintf(in.w, "%s", name)
+		for i := range scenarios {
+			fmt.Fprintf(in.w, "\t%s", cols[i][r])
+		}
+		fmt.Fprintf(in.w, "\n")
+	}
+	return nil
+}
+
+func (in *input) scenarioMenu(db *DB) error {
+	items := []string{"create scenario from live plan [c]", "alter scenario [a]", "compare scenarios [v]", "delete scenario [d]", "main Menu [press enter]"}
+	for _, i := range items {
+		fmt.Fprintf(in.w, "%s\n", i)
+	}
+
+	c, err := in.getString("Choice: ")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c == "c":
+		if err = in.createScenario(db); err != nil {
+			return err
+		}
+	case c == "a":
+		if err = in.alterScenario(db); err != nil {
+			return err
+		}
+	case c == "v":
+		if err = in.compareScenarios(db); err != nil {
+			return err
+		}
+	case c == "d":
+		s, err := in.chooseScenario(db)
+		if err != nil {
+			return err
+		}
+		if err = db.deleteScenario(s); err != nil {
+			return err
+		}
+	case c == "":
+		return nil
+	default:
+		return fmt.Errorf("%s is not a valid choice", c)
+	}
+
+	return nil
+}
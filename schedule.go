@@ -0,0 +1,444 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nightOrder sorts hours of a night, so the hours after midnight come after
+// those of the evening.
+func nightOrder(hour int) int {
+	return (hour + 12) % 24
+}
+
+// window is a time span of a night from the hour start to the hour finish.
+type window struct {
+	start, finish int
+}
+
+// covers returns whether hour is part of w.
+func (w window) covers(hour int) bool {
+	return nightOrder(w.start) <= nightOrder(hour) && nightOrder(hour) < nightOrder(w.finish)
+}
+
+func (w window) overlaps(o window) bool {
+	return nightOrder(w.start) < nightOrder(o.finish) && nightOrder(o.start) < nightOrder(w.finish)
+}
+
+func (w window) String() string {
+	return fmt.Sprintf("%02d:00–%02d:00", w.start, w.finish)
+}
+
+// getWindow asks when what starts and ends.
+func (in *input) getWindow(what string) (window, error) {
+	start, err := in.getInt("Hour the %s starts [0-23]: ", what)
+	if err != nil {
+		return window{}, err
+	}
+	finish, err := in.getInt("Hour the %s ends [0-23]: ", what)
+	if err != nil {
+		return window{}, err
+	}
+	w := window{start, finish}
+	if start < 0 || start > 23 || finish < 0 || finish > 23 {
+		return window{}, fmt.Errorf("hours have to be between 0 and 23")
+	}
+	if nightOrder(finish) <= nightOrder(start) {
+		return window{}, fmt.Errorf("%s %s ends before it starts", what, w)
+	}
+	return w, nil
+}
+
+// hours returns the hours of profile in the order of the night.
+func hours(profile map[int]float64) []int {
+	var hs []int
+	for h := range profile {
+		hs = append(hs, h)
+	}
+	sort.Slice(hs, func(i, j int) bool { return nightOrder(hs[i]) < nightOrder(hs[j]) })
+	return hs
+}
+
+var errNoProfile = errors.New("there is no hourly profile and no sales were recorded")
+
+// hourlyProfile returns the share of the cocktails sold in each hour of the
+// fest at date. It is the profile entered for the fest or, if there is none,
+// the one of the sales recorded for it in tally mode or, if there are none
+// either, the one of the sales of all other fests.
+func (db *DB) hourlyProfile(date string) (map[int]float64, error) {
+	profile := make(map[int]float64)
+
+	rows, err := db.Query("SELECT hour, share FROM festhours WHERE fest = (SELECT id FROM fests WHERE date = $1)", date)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var h int
+		var share float64
+
+		if err := rows.Scan(&h, &share); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		profile[h] = share
+	}
+	rows.Close()
+	if len(profile) > 0 {
+		return profile, nil
+	}
+
+	profile, err = db.salesProfile("SELECT time FROM sales WHERE fest = (SELECT id FROM fests WHERE date = $1)", date)
+	if err != errNoProfile {
+		return profile, err
+	}
+	return db.salesProfile("SELECT time FROM sales WHERE fest <> (SELECT id FROM fests WHERE date = $1)", date)
+}
+
+// salesProfile returns the share of the sales selected by query in each hour.
+func (db *DB) salesProfile(query string, args ...interface{}) (map[int]float64, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profile := make(map[int]float64)
+	var n int
+	for rows.Next() {
+		var t time.Time
+
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		profile[t.Hour()]++
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, errNoProfile
+	}
+
+	for h := range profile {
+		profile[h] /= float64(n)
+	}
+	return profile, nil
+}
+
+func (in *input) setProfile(db *DB) error {
+	choice, err := in.getString("Expected share of sales per hour [e.g. 21:1 22:3 23:3 0:2 1:1]: ")
+	if err != nil {
+		return err
+	}
+
+	profile := make(map[int]float64)
+	var sum float64
+	for _, f := range strings.Fields(choice) {
+		parts := strings.SplitN(f, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%s is not of the form hour:share", f)
+		}
+		h, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return err
+		}
+		if h < 0 || h > 23 {
+			return fmt.Errorf("%d is not a valid hour", h)
+		}
+		share, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return err
+		}
+		if share < 0 {
+			return fmt.Errorf("%.2f is not a valid share", share)
+		}
+		profile[h] += share
+		sum += share
+	}
+	if sum <= 0 {
+		return fmt.Errorf("the shares have to add up to more than 0")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM festhours WHERE fest = (SELECT id FROM fests WHERE date = $1)", cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	for h, share := range profile {
+		_, err := tx.Exec("INSERT INTO festhours (fest, hour, share) VALUES ((SELECT id FROM fests WHERE date = $1), $2, $3)", cfg.Current, h, share/sum)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// sell records that one cocktail was sold now for price. helper is the
+// helper getting it as a staff drink, empty for guests.
+func (db *DB) sell(cocktail string, price int, helper string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = recordSale(tx, cocktail, price, helper); err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// recordSale records cocktail as sold now in tx.
+func recordSale(tx *sql.Tx, cocktail string, price int, helper string) error {
+	var h interface{}
+	if helper != "" {
+		h = helper
+	}
+
+	_, err := tx.Exec("INSERT INTO sales (fest, cocktail, time, price, helper) VALUES ((SELECT id FROM fests WHERE date = $1), (SELECT id FROM cocktails WHERE name = $2), $3, $4, (SELECT id FROM helpers WHERE name = $5))", cfg.Current, cocktail, time.Now(), price, h)
+	if err != nil {
+		return err
+	}
+	// staff drinks are no demand of guests and not counted as sold
+	if helper == "" {
+		_, err = tx.Exec("UPDATE festcocktails SET sold = COALESCE(sold, 0) + 1 WHERE fest = (SELECT id FROM fests WHERE date = $1) AND cocktails = (SELECT id FROM cocktails WHERE name = $2)", cfg.Current, cocktail)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tally records the cocktails of the current fest as they are sold, at the
+// price active at the time, and the deals taken.
+func (in *input) tally(db *DB) error {
+	f, err := db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+	tokens, err := db.getTokens(cfg.Current)
+	if err != nil {
+		return err
+	}
+	prules, err := db.getPriceRules(cfg.Current)
+	if err != nil {
+		return err
+	}
+	bundles, err := db.getBundles(cfg.Current)
+	if err != nil {
+		return err
+	}
+	// pay returns what to collect for price
+	pay := func(price int) string {
+		if len(tokens) == 0 {
+			return formatEuro(price)
+		}
+		count, err := tokenCount(price, tokens)
+		if err != nil {
+			return err.Error()
+		}
+		return formatTokens(count)
+	}
+
+	for {
+		hour := time.Now().Hour()
+		for i, c := range f.cocktails {
+			fmt.Fprintf(in.w, "%d %s\t%s\n", i, c, pay(activePrice(f, prules, c, hour)))
+		}
+		for i, b := range bundles {
+			fmt.Fprintf(in.w, "b%d %s\t%s\n", i, b.name, pay(b.price))
+		}
+		choice, err := in.getString("Which cocktail was sold? [append s for a staff drink, press enter to stop]: ")
+		if err != nil {
+			return err
+		}
+		if choice == "" {
+			return nil
+		}
+		if strings.HasPrefix(choice, "b") {
+			sel, err := strconv.Atoi(choice[1:])
+			if err != nil || sel < 0 || sel >= len(bundles) {
+				fmt.Fprintf(in.w, "%s is not a valid choice\n", choice)
+				continue
+			}
+			if err := db.sellBundle(f, bundles[sel]); err != nil {
+				return err
+			}
+			fmt.Fprintf(in.w, "Sold %s for %s.\n", bundles[sel].name, pay(bundles[sel].price))
+			continue
+		}
+		staff := strings.HasSuffix(choice, "s")
+		choice = strings.TrimSuffix(choice, "s")
+
+		sel, err := strconv.Atoi(choice)
+		if err != nil {
+			fmt.Fprintf(in.w, "%s is not a valid choice\n", choice)
+			continue
+		}
+		if sel < 0 || sel >= len(f.cocktails) {
+			fmt.Fprintf(in.w, "%d is not a valid choice\n", sel)
+			continue
+		}
+
+		c := f.cocktails[sel]
+		if staff {
+			if err := in.staffDrink(db, c); err != nil {
+				fmt.Fprintf(in.w, "%s\n", err)
+			}
+			continue
+		}
+		price := activePrice(f, prules, c, hour)
+		if err := db.sell(c, price, ""); err != nil {
+			return err
+		}
+		fmt.Fprintf(in.w, "Sold %s for %s.\n", c, pay(price))
+	}
+}
+
+// staffDrink records cocktail as a free staff drink if the helper has any
+// left of their allowance.
+func (in *input) staffDrink(db *DB, cocktail string) error {
+	allowed, taken, err := db.staffDrinks()
+	if err != nil {
+		return err
+	}
+	var helpers []string
+	for h := range allowed {
+		helpers = append(helpers, h)
+	}
+	sort.Strings(helpers)
+
+	for i, h := range helpers {
+		fmt.Fprintf(in.w, "%d %s\t%d left\n", i, h, allowed[h]-taken[h])
+	}
+	sel, err := in.getInt("Which helper gets the %s? ", cocktail)
+	if err != nil {
+		return err
+	}
+	if sel < 0 || sel >= len(helpers) {
+		return fmt.Errorf("%d is not a valid choice", sel)
+	}
+	h := helpers[sel]
+	if taken[h] >= allowed[h] {
+		return fmt.Errorf("%s has no staff drinks left", h)
+	}
+
+	if err := db.sell(cocktail, 0, h); err != nil {
+		return err
+	}
+	fmt.Fprintf(in.w, "Gave %s to %s.\n", cocktail, h)
+	return nil
+}
+
+// printSchedule prints the demand curve of the current fest and when to bring
+// bottles from the cellar, how much of each consumable like ice is needed and
+// when to prepare premixes.
+func (in *input) printSchedule(db *DB) error {
+	profile, err := db.hourlyProfile(cfg.Current)
+	if err != nil {
+		return err
+	}
+	f, err := db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+	batched, err := db.getBatched()
+	if err != nil {
+		return err
+	}
+	bs, err := db.getBottles()
+	if err != nil {
+		return err
+	}
+	consumables, err := db.consumableNeeds(f)
+	if err != nil {
+		return err
+	}
+	all, err := db.getConsumables()
+	if err != nil {
+		return err
+	}
+	units := make(map[string]string)
+	for _, c := range all {
+		units[c.name] = c.unit
+	}
+
+	needs := make(map[string]float64)
+	for _, c := range f.cocktails {
+		ingreds, err := db.cocktailIngredients(c)
+		if err != nil {
+			return err
+		}
+		for ing, amount := range ingreds {
+			needs[ing] += amount * float64(f.cocktailamounts[c])
+		}
+	}
+	var ingreds []string
+	for ing := range needs {
+		ingreds = append(ingreds, ing)
+	}
+	sort.Strings(ingreds)
+	var cons []string
+	for c := range consumables {
+		cons = append(cons, c)
+	}
+	sort.Strings(cons)
+
+	total := float64(totalPlanned(f))
+	fmt.Fprintf(in.w, "hour\tshare\tcocktails\n")
+	for _, h := range hours(profile) {
+		fmt.Fprintf(in.w, "%02d:00\t%.0f %%\t%.0f\t%s\n", h, profile[h]*100, total*profile[h], strings.Repeat("#", int(math.Round(profile[h]*50))))
+	}
+
+	fmt.Fprintf(in.w, "\n")
+	share := 0.0
+	brought := make(map[string]int)
+	for _, h := range hours(profile) {
+		share += profile[h]
+		fmt.Fprintf(in.w, "Before %02d:00:\n", h)
+
+		for _, ing := range ingreds {
+			if batched[ing] {
+				if a := needs[ing] * profile[h]; a > 0 {
+					fmt.Fprintf(in.w, "  prepare\t%.2f l\t%s\n", a, ing)
+				}
+				continue
+			}
+			unit := bs[ing].unit
+			n := bottles(needs[ing]*share, unit) - brought[ing]
+			if n > 0 {
+				fmt.Fprintf(in.w, "  bring\t%d × %.2f l\t%s\n", n, unit, ing)
+				brought[ing] += n
+			}
+		}
+		for _, c := range cons {
+			if a := consumables[c] * profile[h]; a > 0 {
+				fmt.Fprintf(in.w, "  have\t%.1f %s\t%s\n", a, units[c], c)
+			}
+		}
+	}
+	return nil
+}
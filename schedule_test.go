@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestNightOrder(t *testing.T) {
+	hours := []int{12, 18, 23, 0, 1, 5, 11}
+	for i := 1; i < len(hours); i++ {
+		if nightOrder(hours[i-1]) >= nightOrder(hours[i]) {
+			t.Errorf("nightOrder(%d) = %d is not before nightOrder(%d) = %d", hours[i-1], nightOrder(hours[i-1]), hours[i], nightOrder(hours[i]))
+		}
+	}
+}
+
+func TestWindowCovers(t *testing.T) {
+	tests := []struct {
+		w    window
+		hour int
+		want bool
+	}{
+		{window{20, 22}, 20, true},
+		{window{20, 22}, 21, true},
+		{window{20, 22}, 22, false},
+		{window{20, 22}, 19, false},
+		// over midnight
+		{window{22, 2}, 23, true},
+		{window{22, 2}, 0, true},
+		{window{22, 2}, 1, true},
+		{window{22, 2}, 2, false},
+		{window{22, 2}, 21, false},
+		{window{22, 22}, 22, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.w.covers(tt.hour); got != tt.want {
+			t.Errorf("%s covers %d = %t, want %t", tt.w, tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestWindowOverlaps(t *testing.T) {
+	tests := []struct {
+		w, o window
+		want bool
+	}{
+		{window{20, 22}, window{21, 23}, true},
+		{window{20, 22}, window{22, 23}, false},
+		{window{22, 2}, window{1, 3}, true},
+		{window{22, 2}, window{2, 4}, false},
+		{window{18, 4}, window{20, 22}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.w.overlaps(tt.o); got != tt.want {
+			t.Errorf("%s overlaps %s = %t, want %t", tt.w, tt.o, got, tt.want)
+		}
+		if got := tt.o.overlaps(tt.w); got != tt.want {
+			t.Errorf("%s overlaps %s = %t, want %t", tt.o, tt.w, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,23 @@ CREATE TABLE cocktails(
 	id INTEGER,
 	-- name is the name of the cocktail
 	name TEXT,
+	-- notes are printed on the menu below the allergens, which are derived
+	-- from TABLE ingredientflags
+	notes TEXT DEFAULT '',
+	-- method is how the cocktail is prepared, e.g. shaken or stirred
+	method TEXT DEFAULT '',
+	-- glass is the glass the cocktail is served in
+	glass TEXT DEFAULT '',
+	-- garnish is what the cocktail is garnished with
+	garnish TEXT DEFAULT '',
+	-- instructions are free-text instructions for the bartender
+	instructions TEXT DEFAULT '',
+	-- photo is the path to a photo of the cocktail
+	photo TEXT DEFAULT '',
+	-- base references the cocktail in TABLE cocktails this is a variant of
+	base INTEGER DEFAULT NULL,
+	-- scale is the factor the recipe of base is scaled with for a variant
+	scale FLOAT DEFAULT 1.0,
 	--
 	PRIMARY KEY(id)
 );
@@ -18,6 +35,28 @@ CREATE TABLE ingredients(
 	name TEXT,
 	-- price gives the current buying price in cents for one liter
 	price INTEGER DEFAULT 0,
+	-- supplier is the name of the shop the ingredient is bought at
+	supplier TEXT DEFAULT '',
+	-- unit is the size of one bottle in liters
+	unit FLOAT DEFAULT 1.0,
+	-- deposit is the bottle deposit in cents for one bottle
+	deposit INTEGER DEFAULT 0,
+	-- batched is 1 if the ingredient is mixed in advance, e.g. syrups
+	batched INTEGER DEFAULT 0,
+	-- abv is the alcohol content in percent by volume
+	abv FLOAT DEFAULT 0.0,
+	-- equivalent is the group of interchangeable ingredients, e.g. white rum
+	equivalent TEXT DEFAULT '',
+	-- factor is how much of the ingredient equals one liter of its group
+	factor FLOAT DEFAULT 1.0,
+	-- location is where the ingredient is stored, e.g. cellar shelf 2
+	location TEXT DEFAULT '',
+	-- empty is the weight of an empty bottle in grams, 0 if unknown
+	empty FLOAT DEFAULT 0.0,
+	-- full is the weight of a full bottle in grams, 0 if unknown
+	full FLOAT DEFAULT 0.0,
+	-- density is in kg per liter, 0 to derive it from empty and full
+	density FLOAT DEFAULT 0.0,
 	--
 	PRIMARY KEY(id)
 );
@@ -31,6 +70,8 @@ CREATE TABLE cocktailingredients(
 	cocktail INTEGER,
 	-- amount is how many liters of this is in the given cocktails
 	amount FLOAT,
+	-- position is the order in which the ingredients are poured
+	position INTEGER DEFAULT 0,
 	--
 	PRIMARY KEY(ingredient, cocktail),
 	FOREIGN KEY(ingredient) REFERENCES ingredients(id),
@@ -52,15 +93,46 @@ CREATE TABLE stock(
 	FOREIGN KEY(ingredient) REFERENCES ingredients(id)
 );
 
+CREATE TABLE lots(
+	-- lots tracks single purchases of perishable ingredients
+	-- the sum of all lots of an ingredient is part of its stock
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- ingredient references the ingredient in TABLE ingredients
+	ingredient INTEGER,
+	-- purchased is the date the lot was bought
+	purchased DATETIME,
+	-- bestbefore is the best-before date of the lot
+	bestbefore DATETIME,
+	-- available gives the liters left of this lot
+	available FLOAT DEFAULT 0.0,
+	--
+	PRIMARY KEY(id),
+	FOREIGN KEY(ingredient) REFERENCES ingredients(id)
+);
+
 CREATE TABLE fests(
 	-- fests contains all cocktails of all fests
 
 	-- id is a sequential identifier
-	id INTEGER
+	id INTEGER,
 	-- date is the identifier to set a cocktail to a fest
 	date TEXT,
+	-- day is the calendar day the fest takes place
+	day DATETIME,
 	-- awaited is the number of people that are awaited for this fest
 	awaited INTEGER,
+	-- guests is the number of people that came, NULL until counted
+	guests INTEGER,
+	-- cupdeposit is the deposit in cents charged for one cup
+	cupdeposit INTEGER DEFAULT 0,
+	-- cupsissued is how many cups were handed out with a deposit
+	cupsissued INTEGER DEFAULT 0,
+	-- cupsreturned is how many cups were brought back
+	cupsreturned INTEGER DEFAULT 0,
+	-- depositreturned is the bottle deposit in cents got back after the fest
+	depositreturned INTEGER DEFAULT 0,
 	--
 	PRIMARY KEY(id)
 );
@@ -76,8 +148,406 @@ CREATE TABLE festcocktails(
 	price INTEGER DEFAULT 0,
 	-- amount is how many cocktails are planned in this given fest
 	amount INTEGER DEFAULT 0,
+	-- sold is how many cocktails were actually sold, NULL if not counted
+	sold INTEGER DEFAULT NULL,
 	--
 	PRIMARY KEY(fest, cocktails),
 	FOREIGN KEY(fest) REFERENCES fests(id),
 	FOREIGN KEY(cocktails) REFERENCES cocktails(id)
 );
+
+CREATE TABLE sales(
+	-- sales contains every cocktail sold, as recorded in tally mode
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- cocktail references the cocktail in TABLE cocktails
+	cocktail INTEGER,
+	-- time is when the cocktail was sold
+	time DATETIME,
+	-- price is what was charged for the cocktail in cents
+	price INTEGER,
+	-- helper references the helper in TABLE helpers a staff drink was given
+	-- to, NULL for cocktails sold to guests
+	helper INTEGER DEFAULT NULL,
+	--
+	PRIMARY KEY(id),
+	FOREIGN KEY(fest) REFERENCES fests(id),
+	FOREIGN KEY(cocktail) REFERENCES cocktails(id),
+	FOREIGN KEY(helper) REFERENCES helpers(id)
+);
+
+CREATE TABLE stockcounts(
+	-- stockcounts contains the counts of the whole stock
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- started is when the count was started
+	started DATETIME,
+	-- finished is when the stock was set to the count, NULL while counting
+	finished DATETIME DEFAULT NULL,
+	--
+	PRIMARY KEY(id)
+);
+
+CREATE TABLE stockcountitems(
+	-- stockcountitems contains what was counted of each ingredient
+
+	-- stockcount references the count in TABLE stockcounts
+	stockcount INTEGER,
+	-- ingredient references the ingredient in TABLE ingredients
+	ingredient INTEGER,
+	-- counted is how many liters were counted
+	counted FLOAT,
+	-- expected is how many liters were in stock before the count was set
+	expected FLOAT DEFAULT NULL,
+	--
+	PRIMARY KEY(stockcount, ingredient),
+	FOREIGN KEY(stockcount) REFERENCES stockcounts(id),
+	FOREIGN KEY(ingredient) REFERENCES ingredients(id)
+);
+
+CREATE TABLE pricerules(
+	-- pricerules contains prices of fest cocktails that differ from
+	-- festcocktails.price in a time window, e.g. during happy hour
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- name describes the rule, e.g. happy hour or last call
+	name TEXT,
+	-- cocktail references the cocktail in TABLE cocktails, NULL for all
+	cocktail INTEGER DEFAULT NULL,
+	-- start is the hour the rule starts, 0 to 23
+	start INTEGER,
+	-- finish is the hour the rule ends, 0 to 23
+	finish INTEGER,
+	-- price is the price in the window in cents, NULL to use factor
+	price INTEGER DEFAULT NULL,
+	-- factor is multiplied with the price of the cocktail in the window
+	factor FLOAT DEFAULT 1.0,
+	--
+	PRIMARY KEY(id),
+	FOREIGN KEY(fest) REFERENCES fests(id),
+	FOREIGN KEY(cocktail) REFERENCES cocktails(id)
+);
+
+CREATE TABLE bundles(
+	-- bundles contains deals of several cocktails for one price
+	-- e.g. 2 for 1 or cocktail and shot
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- name is the name of the deal
+	name TEXT,
+	-- price is the price of the whole bundle in cents
+	price INTEGER,
+	--
+	PRIMARY KEY(id),
+	FOREIGN KEY(fest) REFERENCES fests(id)
+);
+
+CREATE TABLE bundlecocktails(
+	-- bundlecocktails maps cocktails to bundles
+
+	-- bundle references the bundle in TABLE bundles
+	bundle INTEGER,
+	-- cocktail references the cocktail in TABLE cocktails
+	cocktail INTEGER,
+	-- amount is how many of the cocktail are in the bundle
+	amount INTEGER,
+	--
+	PRIMARY KEY(bundle, cocktail),
+	FOREIGN KEY(bundle) REFERENCES bundles(id),
+	FOREIGN KEY(cocktail) REFERENCES cocktails(id)
+);
+
+CREATE TABLE tokens(
+	-- tokens contains the drink tokens sold at the cash desk of a fest
+	-- cocktails are paid with tokens at the bar if a fest has any
+
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- value is the value of one token in cents
+	value INTEGER,
+	-- sold is how many tokens were sold at the cash desk
+	sold INTEGER DEFAULT 0,
+	-- redeemed is how many tokens were collected at the bar
+	redeemed INTEGER DEFAULT 0,
+	--
+	PRIMARY KEY(fest, value),
+	FOREIGN KEY(fest) REFERENCES fests(id)
+);
+
+CREATE TABLE festhours(
+	-- festhours contains the expected share of sales per hour of a fest
+
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- hour is the hour of the day, 0 to 23
+	hour INTEGER,
+	-- share is the share of the cocktails sold in this hour
+	share FLOAT,
+	--
+	PRIMARY KEY(fest, hour),
+	FOREIGN KEY(fest) REFERENCES fests(id)
+);
+
+CREATE TABLE helpers(
+	-- helpers contains the volunteers working at fests
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- name is the name of the helper
+	name TEXT UNIQUE,
+	-- contact is how to reach the helper, e.g. a phone number
+	contact TEXT DEFAULT '',
+	--
+	PRIMARY KEY(id)
+);
+
+CREATE TABLE shifts(
+	-- shifts contains the time slots bartenders work in at a fest
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- start is the hour the shift starts, 0 to 23
+	start INTEGER,
+	-- finish is the hour the shift ends, 0 to 23
+	finish INTEGER,
+	--
+	PRIMARY KEY(id),
+	FOREIGN KEY(fest) REFERENCES fests(id)
+);
+
+CREATE TABLE shifthelpers(
+	-- shifthelpers maps helpers to the shifts they work in
+
+	-- shift references the shift in TABLE shifts
+	shift INTEGER,
+	-- helper references the helper in TABLE helpers
+	helper INTEGER,
+	--
+	PRIMARY KEY(shift, helper),
+	FOREIGN KEY(shift) REFERENCES shifts(id),
+	FOREIGN KEY(helper) REFERENCES helpers(id)
+);
+
+CREATE TABLE scenarios(
+	-- scenarios contains named drafts of the plan of a fest
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- name is the name of the scenario, e.g. small menu
+	name TEXT,
+	-- awaited is the number of guests awaited in this scenario
+	awaited INTEGER,
+	--
+	PRIMARY KEY(id),
+	UNIQUE(fest, name),
+	FOREIGN KEY(fest) REFERENCES fests(id)
+);
+
+CREATE TABLE scenariococktails(
+	-- scenariococktails maps cocktails to scenarios like festcocktails
+	-- maps them to fests
+
+	-- scenario references the scenario in TABLE scenarios
+	scenario INTEGER,
+	-- cocktails references the cocktails in TABLE cocktails
+	cocktails INTEGER,
+	-- price is the selling price of one cocktail in cents
+	price INTEGER DEFAULT 0,
+	-- amount is how many cocktails are planned in this scenario
+	amount INTEGER DEFAULT 0,
+	--
+	PRIMARY KEY(scenario, cocktails),
+	FOREIGN KEY(scenario) REFERENCES scenarios(id),
+	FOREIGN KEY(cocktails) REFERENCES cocktails(id)
+);
+
+CREATE TABLE purchases(
+	-- purchases contains the purchase orders for a fest
+	-- there is one purchase order per fest and supplier
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- supplier is the name of the shop the order goes to
+	supplier TEXT,
+	-- created is the date the order was created
+	created DATETIME,
+	--
+	PRIMARY KEY(id),
+	FOREIGN KEY(fest) REFERENCES fests(id)
+);
+
+CREATE TABLE purchaseitems(
+	-- purchaseitems maps ingredients to purchase orders
+
+	-- purchase references the order in TABLE purchases
+	purchase INTEGER,
+	-- ingredient references the ingredient in TABLE ingredients
+	ingredient INTEGER,
+	-- amount is how many liters were ordered
+	amount FLOAT DEFAULT 0.0,
+	-- price is the expected cost of the ordered amount in cents
+	price INTEGER DEFAULT 0,
+	-- received is how many liters were actually bought
+	received FLOAT DEFAULT 0.0,
+	-- paid is the actual cost of the received amount in cents
+	paid INTEGER DEFAULT 0,
+	--
+	PRIMARY KEY(purchase, ingredient),
+	FOREIGN KEY(purchase) REFERENCES purchases(id),
+	FOREIGN KEY(ingredient) REFERENCES ingredients(id)
+);
+
+CREATE TABLE receipts(
+	-- receipts contains the purchase receipts of a fest
+	-- the committee member who paid gets the amount reimbursed
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- fest references the fest in TABLE fests
+	fest INTEGER,
+	-- payer is the name of the person who paid
+	payer TEXT,
+	-- amount is the total of the receipt in cents
+	amount INTEGER DEFAULT 0,
+	-- date is the date on the receipt
+	date DATETIME,
+	-- description says what was bought
+	description TEXT DEFAULT '',
+	-- reimbursed is 1 once the payer got the money back
+	reimbursed INTEGER DEFAULT 0,
+	--
+	PRIMARY KEY(id),
+	FOREIGN KEY(fest) REFERENCES fests(id)
+);
+
+CREATE TABLE receiptitems(
+	-- receiptitems maps the ingredients on receipts to them
+
+	-- receipt references the receipt in TABLE receipts
+	receipt INTEGER,
+	-- purchase references the order in TABLE purchases, NULL for none
+	purchase INTEGER,
+	-- ingredient references the ingredient in TABLE ingredients
+	ingredient INTEGER,
+	--
+	PRIMARY KEY(receipt, purchase, ingredient),
+	FOREIGN KEY(receipt) REFERENCES receipts(id),
+	FOREIGN KEY(purchase, ingredient) REFERENCES purchaseitems(purchase, ingredient)
+);
+
+CREATE TABLE consumables(
+	-- consumables contains everything needed at the bar that is not poured
+	-- e.g. ice, cups, straws, napkins or fruit slices
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- name is the name of the consumable
+	name TEXT,
+	-- unit is the unit the consumable is counted in, e.g. kg or pcs
+	unit TEXT DEFAULT 'pcs',
+	-- price gives the current buying price in cents for one unit
+	price INTEGER DEFAULT 0,
+	-- perserving is how many units are needed for every cocktail served
+	perserving FLOAT DEFAULT 0.0,
+	-- available gives the units in stock
+	available FLOAT DEFAULT 0.0,
+	-- cutfrom is the fruit this is cut from, e.g. lime for lime wedges
+	cutfrom TEXT DEFAULT '',
+	-- perfruit is how many units are cut from one fruit
+	perfruit FLOAT DEFAULT 0.0,
+	--
+	PRIMARY KEY(id)
+);
+
+CREATE TABLE cocktailconsumables(
+	-- cocktailconsumables maps consumables to cocktails
+	-- this is needed in addition to consumables.perserving
+
+	-- consumable references the consumable in TABLE consumables
+	consumable INTEGER,
+	-- cocktail references the cocktail in TABLE cocktails
+	cocktail INTEGER,
+	-- amount is how many units are needed for one cocktail
+	amount FLOAT,
+	--
+	PRIMARY KEY(consumable, cocktail),
+	FOREIGN KEY(consumable) REFERENCES consumables(id),
+	FOREIGN KEY(cocktail) REFERENCES cocktails(id)
+);
+
+CREATE TABLE ingredientparts(
+	-- ingredientparts contains the recipes of house-made ingredients
+	-- e.g. sugar syrup is made of sugar and water
+
+	-- ingredient references the house-made ingredient in TABLE ingredients
+	ingredient INTEGER,
+	-- part references the ingredient it is made of in TABLE ingredients
+	part INTEGER,
+	-- amount is the amount of part needed for one liter of ingredient
+	amount FLOAT,
+	--
+	PRIMARY KEY(ingredient, part),
+	FOREIGN KEY(ingredient) REFERENCES ingredients(id),
+	FOREIGN KEY(part) REFERENCES ingredients(id)
+);
+
+CREATE TABLE ingredientflags(
+	-- ingredientflags contains allergen and dietary flags of ingredients
+	-- e.g. lactose, nuts, egg white, gluten, caffeine or vegan
+
+	-- ingredient references the ingredient in TABLE ingredients
+	ingredient INTEGER,
+	-- flag is the name of the flag
+	flag TEXT,
+	--
+	PRIMARY KEY(ingredient, flag),
+	FOREIGN KEY(ingredient) REFERENCES ingredients(id)
+);
+
+CREATE TABLE cocktailtags(
+	-- cocktailtags contains tags and categories of cocktails
+	-- e.g. sour, tiki, classic, sweet, seasonal or house special
+
+	-- cocktail references the cocktail in TABLE cocktails
+	cocktail INTEGER,
+	-- tag is the name of the tag
+	tag TEXT,
+	--
+	PRIMARY KEY(cocktail, tag),
+	FOREIGN KEY(cocktail) REFERENCES cocktails(id)
+);
+
+CREATE TABLE variantsubstitutions(
+	-- variantsubstitutions replaces ingredients of the base cocktail in a variant
+
+	-- cocktail references the variant in TABLE cocktails
+	cocktail INTEGER,
+	-- ingredient references the replaced ingredient in TABLE ingredients
+	ingredient INTEGER,
+	-- substitute references the replacement in TABLE ingredients
+	-- if it is NULL the ingredient is left out
+	substitute INTEGER DEFAULT NULL,
+	-- factor is the amount of substitute per amount of ingredient
+	factor FLOAT DEFAULT 1.0,
+	--
+	PRIMARY KEY(cocktail, ingredient),
+	FOREIGN KEY(cocktail) REFERENCES cocktails(id),
+	FOREIGN KEY(ingredient) REFERENCES ingredients(id),
+	FOREIGN KEY(substitute) REFERENCES ingredients(id)
+);
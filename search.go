@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const searchHelp = "[words, tag:<tag>, with:<ingredient>, available; press enter for all]"
+
+func (db *DB) cocktailTags() (map[string][]string, error) {
+	rows, err := db.Query("SELECT cocktails.name, cocktailtags.tag FROM cocktailtags JOIN cocktails ON cocktails.id = cocktailtags.cocktail ORDER BY cocktailtags.tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var name, tag string
+
+		if err := rows.Scan(&name, &tag); err != nil {
+			return nil, err
+		}
+		tags[name] = append(tags[name], tag)
+	}
+	return tags, rows.Err()
+}
+
+// matchCocktails returns the names of all cocktails matching query. A query
+// consists of words that all have to match:
+//
+//	tag:<tag>          the cocktail is tagged with tag, write spaces as '-'
+//	with:<ingredient>  one of the ingredients contains ingredient
+//	available          all ingredients or substitutes are in stock and not expired
+//	<word>             any text of the cocktail contains word
+func (db *DB) matchCocktails(cocktails []cocktail, query string) (map[string]bool, error) {
+	tags, err := db.cocktailTags()
+	if err != nil {
+		return nil, err
+	}
+	stock, err := db.usableStock(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	e, err := db.getEquivalents()
+	if err != nil {
+		return nil, err
+	}
+
+	match := make(map[string]bool)
+	for _, c := range cocktails {
+		match[c.name] = true
+
+		var ingreds []string
+		for ing := range c.ingredients {
+			ingreds = append(ingreds, strings.ToLower(ing))
+		}
+		text := strings.ToLower(strings.Join(append([]string{c.name, c.method, c.glass, c.garnish, c.instructions, strings.Join(tags[c.name], " ")}, ingreds...), " "))
+
+		for _, word := range strings.Fields(strings.ToLower(query)) {
+			var ok bool
+			switch {
+			case strings.HasPrefix(word, "tag:"):
+				for _, t := range tags[c.name] {
+					ok = ok || strings.Replace(strings.ToLower(t), " ", "-", -1) == word[4:]
+				}
+			case strings.HasPrefix(word, "with:"):
+				for _, ing := range ingreds {
+					ok = ok || strings.Contains(ing, word[5:])
+				}
+			case word == "available":
+				ok = true
+				for ing := range c.ingredients {
+					ok = ok && e.inStock(ing, stock)
+				}
+			default:
+				ok = strings.Contains(text, word)
+			}
+			match[c.name] = match[c.name] && ok
+		}
+	}
+	return match, nil
+}
+
+// searchCocktails asks for a search query and returns the names of the
+// matching cocktails.
+func (in *input) searchCocktails(cocktails []cocktail, db *DB) (map[string]bool, error) {
+	query, err := in.getString("Search %s: ", searchHelp)
+	if err != nil {
+		return nil, err
+	}
+	return db.matchCocktails(cocktails, query)
+}
+
+func (in *input) alterTags(name string, db *DB) error {
+	tags, err := db.cocktailTags()
+	if err != nil {
+		return err
+	}
+
+	all := make(map[string]bool)
+	for _, ts := range tags {
+		for _, t := range ts {
+			all[t] = true
+		}
+	}
+	var known []string
+	for t := range all {
+		known = append(known, t)
+	}
+	sort.Strings(known)
+
+	fmt.Fprintf(in.w, "Tags in use: %s\n", strings.Join(known, ", "))
+	fmt.Fprintf(in.w, "Current tags of %s: %s\n", name, strings.Join(tags[name], ", "))
+
+	choice, err := in.getString("Tags of %s [separate with ',']: ", name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM cocktailtags WHERE cocktail = (SELECT id FROM cocktails WHERE name = $1)", name)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range strings.Split(choice, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+
+		_, err := tx.Exec("INSERT OR IGNORE INTO cocktailtags (cocktail, tag) VALUES ((SELECT id FROM cocktails WHERE name = $1), $2)", name, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
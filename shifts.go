@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// shift is a time slot of the current fest and the helpers working in it.
+type shift struct {
+	id int
+	window
+	helpers []string
+}
+
+func (db *DB) getHelpers() ([]string, error) {
+	rows, err := db.Query("SELECT name FROM helpers ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var helpers []string
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		helpers = append(helpers, name)
+	}
+	return helpers, rows.Err()
+}
+
+// getShifts returns the shifts of the current fest in the order of the night.
+func (db *DB) getShifts() ([]shift, error) {
+	rows, err := db.Query("SELECT id, start, finish FROM shifts WHERE fest = (SELECT id FROM fests WHERE date = $1)", cfg.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	var shifts []shift
+	for rows.Next() {
+		var s shift
+
+		if err := rows.Scan(&s.id, &s.start, &s.finish); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		shifts = append(shifts, s)
+	}
+	rows.Close()
+
+	for i := range shifts {
+		rows, err := db.Query("SELECT helpers.name FROM shifthelpers JOIN helpers ON helpers.id = shifthelpers.helper WHERE shift = $1 ORDER BY helpers.name", shifts[i].id)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name string
+
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			shifts[i].helpers = append(shifts[i].helpers, name)
+		}
+		rows.Close()
+	}
+
+	sort.Slice(shifts, func(i, j int) bool { return nightOrder(shifts[i].start) < nightOrder(shifts[j].start) })
+	return shifts, nil
+}
+
+// bartenders returns how many bartenders are needed in each hour of the
+// current fest to make the cocktails expected in it.
+func (db *DB) bartenders() (map[int]int, error) {
+	profile, err := db.hourlyProfile(cfg.Current)
+	if err != nil {
+		return nil, err
+	}
+	f, err := db.getFest(cfg.Current)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PerBartender <= 0 {
+		return nil, fmt.Errorf("PerBartender has to be set in the config")
+	}
+
+	total := float64(totalPlanned(f))
+	needed := make(map[int]int)
+	for h, share := range profile {
+		needed[h] = int(math.Ceil(total * share / cfg.PerBartender))
+		if needed[h] < 1 && share > 0 {
+			needed[h] = 1
+		}
+	}
+	return needed, nil
+}
+
+// required returns how many bartenders s needs, which is the most needed in
+// any of its hours.
+func (s shift) required(needed map[int]int) int {
+	var n int
+	for h, b := range needed {
+		if s.covers(h) && b > n {
+			n = b
+		}
+	}
+	return n
+}
+
+// shiftConflicts returns a description of every helper working in
+// overlapping shifts, every shift with less helpers than needed and every
+// hour with demand but no shift.
+func shiftConflicts(shifts []shift, needed map[int]int) []string {
+	var conflicts []string
+
+	for i, s := range shifts {
+		for _, o := range shifts[i+1:] {
+			if !s.overlaps(o.window) {
+				continue
+			}
+			for _, h := range s.helpers {
+				for _, oh := range o.helpers {
+					if h == oh {
+						conflicts = append(conflicts, fmt.Sprintf("%s works in the overlapping shifts %s and %s", h, s, o))
+					}
+				}
+			}
+		}
+	}
+
+	for _, s := range shifts {
+		if r := s.required(needed); len(s.helpers) < r {
+			conflicts = append(conflicts, fmt.Sprintf("shift %s needs %d bartenders but has %d", s, r, len(s.helpers)))
+		}
+	}
+
+	var hs []int
+	for h, b := range needed {
+		if b > 0 {
+			hs = append(hs, h)
+		}
+	}
+	sort.Slice(hs, func(i, j int) bool { return nightOrder(hs[i]) < nightOrder(hs[j]) })
+	for _, h := range hs {
+		covered := false
+		for _, s := range shifts {
+			covered = covered || s.covers(h)
+		}
+		if !covered {
+			conflicts = append(conflicts, fmt.Sprintf("no shift covers %02d:00", h))
+		}
+	}
+	return conflicts
+}
+
+// staffDrinks returns how many staff drinks each helper is allowed at the
+// current fest and how many they already had.
+func (db *DB) staffDrinks() (allowed, taken map[string]int, err error) {
+	shifts, err := db.getShifts()
+	if err != nil {
+		return nil, nil, err
+	}
+	allowed = make(map[string]int)
+	for _, s := range shifts {
+		for _, h := range s.helpers {
+			allowed[h] += cfg.StaffDrinks
+		}
+	}
+
+	rows, err := db.Query("SELECT helpers.name, COUNT(*) FROM sales JOIN helpers ON helpers.id = sales.helper WHERE fest = (SELECT id FROM fests WHERE date = $1) GROUP BY helpers.name", cfg.Current)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	taken = make(map[string]int)
+	for rows.Next() {
+		var name string
+		var n int
+
+		if err := rows.Scan(&name, &n); err != nil {
+			return nil, nil, err
+		}
+		taken[name] = n
+	}
+	return allowed, taken, rows.Err()
+}
+
+func (in *input) addHelper(db *DB) error {
+	name, err := in.getString("Name of the helper: ")
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("a helper needs a name")
+	}
+	contact, err := in.getString("How to reach %s [e.g. phone number]: ", name)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("INSERT INTO helpers (name, contact) VALUES ($1, $2)", name, contact)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) addShift(db *DB) error {
+	w, err := in.getWindow("shift")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("INSERT INTO shifts (fest, start, finish) VALUES ((SELECT id FROM fests WHERE date = $1), $2, $3)", cfg.Current, w.start, w.finish)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// assignShift sets the helpers working in a shift. Helpers can not be
+// assigned to a shift overlapping one they already work in.
+func (in *input) assignShift(db *DB) error {
+	shifts, err := db.getShifts()
+	if err != nil {
+		return err
+	}
+	helpers, err := db.getHelpers()
+	if err != nil {
+		return err
+	}
+
+	for i, s := range shifts {
+		fmt.Fprintf(in.w, "%d\t%s\t%s\n", i, s, strings.Join(s.helpers, ", "))
+	}
+	sel, err := in.getInt("Which shift do you want to assign helpers to? ")
+	if err != nil {
+		return err
+	}
+	if sel < 0 || sel >= len(shifts) {
+		return fmt.Errorf("%d is not a valid choice", sel)
+	}
+	s := shifts[sel]
+
+	for i, h := range helpers {
+		fmt.Fprintf(in.w, "%d\t%s\n", i, h)
+	}
+	choice, err := in.getString("Helpers working in %s [separate with ',']: ", s)
+	if err != nil {
+		return err
+	}
+
+	var assigned []string
+	for _, c := range strings.Split(choice, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		id, err := strconv.Atoi(c)
+		if err != nil {
+			return err
+		}
+		if id < 0 || id >= len(helpers) {
+			return fmt.Errorf("%d is not a valid choice", id)
+		}
+
+		for _, o := range shifts {
+			if o.id == s.id || !o.overlaps(s.window) {
+				continue
+			}
+			for _, h := range o.helpers {
+				if h == helpers[id] {
+					return fmt.Errorf("%s already works in the overlapping shift %s", h, o)
+				}
+			}
+		}
+		assigned = append(assigned, helpers[id])
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM shifthelpers WHERE shift = $1", s.id)
+	if err != nil {
+		return err
+	}
+	for _, h := range assigned {
+		_, err := tx.Exec("INSERT OR IGNORE INTO shifthelpers (shift, helper) VALUES ($1, (SELECT id FROM helpers WHERE name = $2))", s.id, h)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// printShifts prints the shift plan of the current fest and writes it to a
+// PDF.
+func (in *input) printShifts(db *DB) error {
+	shifts, err := db.getShifts()
+	if err != nil {
+		return err
+	}
+	needed, err := db.bartenders()
+	if err != nil {
+		fmt.Fprintf(in.w, "Warning: %s, the bartenders needed are unknown.\n", err)
+		needed = make(map[int]int)
+	}
+	allowed, taken, err := db.staffDrinks()
+	if err != nil {
+		return err
+	}
+
+	var text bytes.Buffer
+	fmt.Fprintf(&text, "# Shifts %s\n", cfg.Current)
+	fmt.Fprintf(in.w, "shift\tneeded\thelpers\n")
+	for _, s := range shifts {
+		fmt.Fprintf(&text, "## %s\n", s)
+		for _, h := range s.helpers {
+			fmt.Fprintf(&text, "%s\n", h)
+		}
+		fmt.Fprintf(in.w, "%s\t%d\t%s\n", s, s.required(needed), strings.Join(s.helpers, ", "))
+	}
+
+	var names []string
+	for h := range allowed {
+		names = append(names, h)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(&text, "\n## Staff drinks\n")
+	fmt.Fprintf(in.w, "helper\tstaff drinks\thad\n")
+	for _, h := range names {
+		fmt.Fprintf(&text, "%s: %d\n", h, allowed[h])
+		fmt.Fprintf(in.w, "%s\t%d\t%d\n", h, allowed[h], taken[h])
+	}
+
+	for _, c := range shiftConflicts(shifts, needed) {
+		fmt.Fprintf(in.w, "Warning: %s.\n", c)
+	}
+
+	file, err := in.getString("File name for the shift plan [without extension]: ")
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		file = "shifts"
+	}
+
+	var doc pdf
+	doc.layout(text.String())
+
+	out, err := os.Create(file + ".pdf")
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err = doc.write(out); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "Shift plan written to %s.pdf\n", file)
+	return nil
+}
+
+func (in *input) shiftMenu(db *DB) error {
+	items := []string{"add helper [h]", "add shift [a]", "assign helpers to shift [s]", "print shift plan [p]", "main Menu [press enter]"}
+	for _, i := range items {
+		fmt.Fprintf(in.w, "%s\n", i)
+	}
+
+	c, err := in.getString("Choice: ")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c == "h":
+		if err = in.addHelper(db); err != nil {
+			return err
+		}
+	case c == "a":
+		if err = in.addShift(db); err != nil {
+			return err
+		}
+	case c == "s":
+		if err = in.assignShift(db); err != nil {
+			return err
+		}
+	case c == "p":
+		if err = in.printShifts(db); err != nil {
+			return err
+		}
+	case c == "":
+		return nil
+	default:
+		return fmt.Errorf("%s is not a valid choice", c)
+	}
+
+	return nil
+}
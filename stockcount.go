@@ -0,0 +1,444 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// countItem is an ingredient to count, where it is stored and what its
+// bottles weigh.
+type countItem struct {
+	ingredient string
+	location   string
+	unit       float64
+	empty      float64
+	full       float64
+	density    float64
+}
+
+// liters returns how many liters are in a bottle of it weighing grams on the
+// scale.
+func (it countItem) liters(grams float64) (float64, error) {
+	if it.empty <= 0 {
+		return 0, fmt.Errorf("the weight of an empty bottle of %s is unknown, enter bottles or liters instead", it.ingredient)
+	}
+	if grams < it.empty {
+		return 0, fmt.Errorf("%.0f g is less than an empty bottle of %s weighs", grams, it.ingredient)
+	}
+
+	density := it.density
+	if density <= 0 && it.full > it.empty && it.unit > 0 {
+		density = (it.full - it.empty) / 1000 / it.unit
+	}
+	if density <= 0 {
+		return 0, fmt.Errorf("neither the density of %s nor the weight of a full bottle is known, enter bottles or liters instead", it.ingredient)
+	}
+
+	l := (grams - it.empty) / 1000 / density
+	if l > it.unit*1.1 {
+		return 0, fmt.Errorf("%.0f g is more than a full bottle of %s weighs", grams, it.ingredient)
+	}
+	return l, nil
+}
+
+// countItems returns all ingredients sorted by location, so a count walks
+// through the storage once.
+func (db *DB) countItems() ([]countItem, error) {
+	rows, err := db.Query("SELECT name, location, unit, empty, full, density FROM ingredients ORDER BY location, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []countItem
+	for rows.Next() {
+		var it countItem
+
+		if err := rows.Scan(&it.ingredient, &it.location, &it.unit, &it.empty, &it.full, &it.density); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// openCount returns the stock count that was paused, or starts a new one.
+func (db *DB) openCount() (id int, started time.Time, resumed bool, err error) {
+	err = db.QueryRow("SELECT id, started FROM stockcounts WHERE finished IS NULL ORDER BY id DESC").Scan(&id, &started)
+	if err == nil {
+		return id, started, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, time.Time{}, false, err
+	}
+
+	started = time.Now()
+	res, err := db.Exec("INSERT INTO stockcounts (started) VALUES ($1)", started)
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	n, err := res.LastInsertId()
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	return int(n), started, false, nil
+}
+
+// counted returns the amounts counted so far in the stock count id.
+func (db *DB) counted(id int) (map[string]float64, error) {
+	rows, err := db.Query("SELECT ingredients.name, stockcountitems.counted FROM stockcountitems JOIN ingredients ON ingredients.id = stockcountitems.ingredient WHERE stockcount = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]float64)
+	for rows.Next() {
+		var ing string
+		var a float64
+
+		if err := rows.Scan(&ing, &a); err != nil {
+			return nil, err
+		}
+		counts[ing] = a
+	}
+	return counts, rows.Err()
+}
+
+// previousCount returns the amounts of the last finished stock count.
+func (db *DB) previousCount() (map[string]float64, error) {
+	var id int
+	err := db.QueryRow("SELECT id FROM stockcounts WHERE finished IS NOT NULL ORDER BY finished DESC, id DESC").Scan(&id)
+	if err == sql.ErrNoRows {
+		return make(map[string]float64), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return db.counted(id)
+}
+
+// parseCount returns the liters of it in s, which sums up bottles as decimals
+// or fractions, e.g. "2 1/2", liters like "0.3l" and scale readings of open
+// bottles like "650g".
+func parseCount(s string, it countItem) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("nothing was entered, enter 0 if there is none")
+	}
+
+	var liters float64
+	for _, f := range fields {
+		switch {
+		case strings.HasSuffix(f, "g"):
+			grams, err := strconv.ParseFloat(strings.TrimSuffix(f, "g"), 64)
+			if err != nil {
+				return 0, err
+			}
+			l, err := it.liters(grams)
+			if err != nil {
+				return 0, err
+			}
+			liters += l
+		case strings.HasSuffix(f, "l"):
+			l, err := strconv.ParseFloat(strings.TrimSuffix(f, "l"), 64)
+			if err != nil {
+				return 0, err
+			}
+			liters += l
+		case strings.Contains(f, "/"):
+			parts := strings.SplitN(f, "/", 2)
+			num, err := strconv.ParseFloat(parts[0], 64)
+			if err != nil {
+				return 0, err
+			}
+			den, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return 0, err
+			}
+			if den == 0 {
+				return 0, fmt.Errorf("%s is not a valid fraction", f)
+			}
+			liters += num / den * it.unit
+		default:
+			n, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return 0, err
+			}
+			liters += n * it.unit
+		}
+	}
+	if liters < 0 {
+		return 0, fmt.Errorf("%s is less than nothing", s)
+	}
+	return liters, nil
+}
+
+// countStock walks through all ingredients by location and asks how much is
+// there without showing the expected stock. Counts are saved as they are
+// entered, so the count can be paused and resumed later. Only when all is
+// counted, the stock is set to the counts at once.
+func (in *input) countStock(db *DB) error {
+	id, started, resumed, err := db.openCount()
+	if err != nil {
+		return err
+	}
+	if resumed {
+		choice, err := in.getString("Resume the count started %s? [press enter to resume, d to discard it]: ", started.Format("2006-01-02 15:04"))
+		if err != nil {
+			return err
+		}
+		if choice == "d" {
+			return db.discardCount(id)
+		}
+	}
+
+	items, err := db.countItems()
+	if err != nil {
+		return err
+	}
+	counts, err := db.counted(id)
+	if err != nil {
+		return err
+	}
+
+	location := "-"
+	for _, it := range items {
+		if _, ok := counts[it.ingredient]; ok {
+			continue
+		}
+		if it.location != location {
+			location = it.location
+			if location == "" {
+				fmt.Fprintf(in.w, "Without location:\n")
+			} else {
+				fmt.Fprintf(in.w, "%s:\n", location)
+			}
+		}
+
+		for {
+			choice, err := in.getString("%s [bottles of %.2f l, e.g. 2 1/2, 0.3l or 650g on the scale, press enter to pause]: ", it.ingredient, it.unit)
+			if err != nil {
+				return err
+			}
+			if choice == "" {
+				fmt.Fprintf(in.w, "The count is paused, choose it again to resume.\n")
+				return nil
+			}
+			a, err := parseCount(choice, it)
+			if err != nil {
+				fmt.Fprintf(in.w, "%s\n", err)
+				continue
+			}
+
+			_, err = db.Exec("INSERT INTO stockcountitems (stockcount, ingredient, counted) VALUES ($1, (SELECT id FROM ingredients WHERE name = $2), $3)", id, it.ingredient, a)
+			if err != nil {
+				return err
+			}
+			counts[it.ingredient] = a
+			break
+		}
+	}
+
+	stock, err := db.getStock()
+	if err != nil {
+		return err
+	}
+	if err := in.printVariance(db, items, counts, stock); err != nil {
+		return err
+	}
+
+	choice, err := in.getString("Set the stock to the count? [y to commit, p to pause, d to discard]: ")
+	if err != nil {
+		return err
+	}
+	switch choice {
+	case "y":
+		return db.commitCount(id, counts, stock)
+	case "d":
+		return db.discardCount(id)
+	default:
+		fmt.Fprintf(in.w, "The count is paused, choose it again to resume.\n")
+		return nil
+	}
+}
+
+// printVariance prints the previous count, the expected stock and the count
+// of every ingredient, how much the count changed since the previous one and
+// how much it differs from the expected stock. The value is that of the
+// difference to the expected stock, which is what went missing or was not
+// booked.
+func (in *input) printVariance(db *DB, items []countItem, counts, stock map[string]float64) error {
+	previous, err := db.previousCount()
+	if err != nil {
+		return err
+	}
+	prices, err := db.getIngredientPrices()
+	if err != nil {
+		return err
+	}
+
+	var total float64
+	fmt.Fprintf(in.w, "ingredient\tprevious count [l]\texpected [l]\tcounted [l]\tsince previous count [l]\tvariance to expected [l]\tvalue of variance\n")
+	for _, it := range items {
+		prev, since := "-", "-"
+		if a, ok := previous[it.ingredient]; ok {
+			prev = fmt.Sprintf("%.2f", a)
+			since = fmt.Sprintf("%+.2f", counts[it.ingredient]-a)
+		}
+		v := counts[it.ingredient] - stock[it.ingredient]
+		value := v * float64(prices[it.ingredient])
+		total += value
+		fmt.Fprintf(in.w, "%s\t%s\t%.2f\t%.2f\t%s\t%+.2f\t%s\n", it.ingredient, prev, stock[it.ingredient], counts[it.ingredient], since, v, formatEuro(int(math.Round(value))))
+	}
+	fmt.Fprintf(in.w, "Total value of the variance to the expected stock: %s\n", formatEuro(int(math.Round(total))))
+	return nil
+}
+
+// commitCount sets the stock to counts, taking what is missing out of the
+// lots, and finishes the stock count id.
+func (db *DB) commitCount(id int, counts, stock map[string]float64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for ing, a := range counts {
+		if err := bookStock(tx, ing, a-stock[ing]); err != nil {
+			return err
+		}
+		if used := stock[ing] - a; used > 0 {
+			if err := consumeLots(tx, ing, used); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec("UPDATE stockcountitems SET expected = $1 WHERE stockcount = $2 AND ingredient = (SELECT id FROM ingredients WHERE name = $3)", stock[ing], id, ing)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stockcounts SET finished = $1 WHERE id = $2", time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (db *DB) discardCount(id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM stockcountitems WHERE stockcount = $1", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM stockcounts WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (in *input) updateLocation(db *DB) error {
+	items, err := db.countItems()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "   ingredient\tlocation\n")
+	for i, it := range items {
+		fmt.Fprintf(in.w, "%d: %s\t%s\n", i, it.ingredient, it.location)
+	}
+
+	update, err := in.getInt("Which item do you want to update? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(items) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+
+	location, err := in.getString("Where is %s stored? [e.g. cellar shelf 2]: ", items[update].ingredient)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE ingredients SET location = $1 WHERE name = $2", location, items[update].ingredient)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// updateWeights sets what a bottle of an ingredient weighs empty and full, so
+// open bottles can be counted on a scale.
+func (in *input) updateWeights(db *DB) error {
+	items, err := db.countItems()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "   ingredient\tbottle [l]\tempty [g]\tfull [g]\tdensity [kg/l]\n")
+	for i, it := range items {
+		fmt.Fprintf(in.w, "%d: %s\t%.2f\t%.0f\t%.0f\t%.3f\n", i, it.ingredient, it.unit, it.empty, it.full, it.density)
+	}
+
+	update, err := in.getInt("Which item do you want to update? ")
+	if err != nil {
+		return err
+	}
+	if update < 0 || update >= len(items) {
+		return fmt.Errorf("%d is not a valid choice", update)
+	}
+	it := items[update]
+
+	empty, err := in.getFloat("Weight of an empty %.2f l bottle of %s [g]: ", it.unit, it.ingredient)
+	if err != nil {
+		return err
+	}
+	full, err := in.getFloat("Weight of a full %.2f l bottle of %s [g]: ", it.unit, it.ingredient)
+	if err != nil {
+		return err
+	}
+	if empty < 0 || full < 0 || (full > 0 && full <= empty) {
+		return fmt.Errorf("a full bottle has to weigh more than an empty one")
+	}
+
+	var density float64
+	choice, err := in.getString("Density of %s [kg/l, press enter to derive it from the weights]: ", it.ingredient)
+	if err != nil {
+		return err
+	}
+	if choice != "" {
+		density, err = strconv.ParseFloat(choice, 64)
+		if err != nil {
+			return err
+		}
+		if density <= 0 {
+			return fmt.Errorf("%.3f is not a valid density", density)
+		}
+	}
+	if empty > 0 && full == 0 && density == 0 {
+		return fmt.Errorf("weights can only be converted to liters with the density or the weight of a full bottle")
+	}
+
+	_, err = db.Exec("UPDATE ingredients SET empty = $1, full = $2, density = $3 WHERE name = $4", empty, full, density, it.ingredient)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseCount(t *testing.T) {
+	weighed := countItem{ingredient: "Rum", unit: 0.7, empty: 400, full: 1100}
+	dense := countItem{ingredient: "Syrup", unit: 0.7, empty: 400, density: 1.4}
+	unweighed := countItem{ingredient: "Gin", unit: 0.7}
+	noDensity := countItem{ingredient: "Liqueur", unit: 0.7, empty: 400}
+
+	tests := []struct {
+		s    string
+		it   countItem
+		want float64
+		err  bool
+	}{
+		{"2", weighed, 1.4, false},
+		{"2 1/2", weighed, 1.75, false},
+		{"0.5", weighed, 0.35, false},
+		{"0.3l", weighed, 0.3, false},
+		{"1 750g", weighed, 1.05, false},
+		{"750g", dense, 0.25, false},
+		{"0", unweighed, 0, false},
+		{"", weighed, 0, true},
+		{"abc", weighed, 0, true},
+		{"1/0", weighed, 0, true},
+		{"-1", weighed, 0, true},
+		{"300g", weighed, 0, true},
+		{"2000g", weighed, 0, true},
+		{"750g", unweighed, 0, true},
+		{"750g", noDensity, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCount(tt.s, tt.it)
+		if (err != nil) != tt.err {
+			t.Errorf("parseCount(%q, %s) error = %v, want error %t", tt.s, tt.it.ingredient, err, tt.err)
+			continue
+		}
+		if !tt.err && math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("parseCount(%q, %s) = %.3f, want %.3f", tt.s, tt.it.ingredient, got, tt.want)
+		}
+	}
+}
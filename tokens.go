@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// token is a kind of drink token sold at a fest.
+type token struct {
+	value    int
+	sold     int
+	redeemed int
+}
+
+// getTokens returns the tokens of the fest at date, most valuable first.
+func (db *DB) getTokens(date string) ([]token, error) {
+	rows, err := db.Query("SELECT value, sold, redeemed FROM tokens WHERE fest = (SELECT id FROM fests WHERE date = $1) ORDER BY value DESC", date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []token
+	for rows.Next() {
+		var t token
+
+		if err := rows.Scan(&t.value, &t.sold, &t.redeemed); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// tokenCount returns how many tokens of each value pay price exactly, using
+// as few tokens as possible.
+func tokenCount(price int, tokens []token) (map[int]int, error) {
+	if price < 0 {
+		return nil, fmt.Errorf("%s can not be paid with tokens", formatEuro(price))
+	}
+
+	// fewest[a] is the fewest tokens paying a cents, last[a] the value of
+	// the last token of them, 0 if a can not be paid
+	fewest := make([]int, price+1)
+	last := make([]int, price+1)
+	for a := 1; a <= price; a++ {
+		fewest[a] = -1
+		for _, t := range tokens {
+			if t.value <= 0 || t.value > a || fewest[a-t.value] < 0 {
+				continue
+			}
+			if n := fewest[a-t.value] + 1; fewest[a] < 0 || n < fewest[a] {
+				fewest[a] = n
+				last[a] = t.value
+			}
+		}
+	}
+	if fewest[price] < 0 {
+		return nil, fmt.Errorf("%s can not be paid with tokens", formatEuro(price))
+	}
+
+	count := make(map[int]int)
+	for a := price; a > 0; a -= last[a] {
+		count[last[a]]++
+	}
+	return count, nil
+}
+
+// formatTokens returns count as e.g. "2 × 1,00 €, 1 × 0,50 €".
+func formatTokens(count map[int]int) string {
+	var values []int
+	for v, n := range count {
+		if n > 0 {
+			values = append(values, v)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(values)))
+
+	var s []string
+	for _, v := range values {
+		s = append(s, fmt.Sprintf("%d × %s", count[v], formatEuro(v)))
+	}
+	return strings.Join(s, ", ")
+}
+
+// checkTokens checks that every cocktail in f can be paid with the tokens of
+// the fest, if it has any, at its price and at every price of a price rule.
+func checkTokens(db *DB, f fest, r rule) (string, error) {
+	tokens, err := db.getTokens(f.date)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return "", nil
+	}
+
+	prules, err := db.getPriceRules(f.date)
+	if err != nil {
+		return "", err
+	}
+	bundles, err := db.getBundles(f.date)
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range f.cocktails {
+		if _, err := tokenCount(f.cocktailprices[c], tokens); err != nil {
+			return fmt.Sprintf("%s costs %s, which can not be paid with tokens", c, formatEuro(f.cocktailprices[c])), nil
+		}
+		for _, pr := range prules {
+			p, ok := pr.apply(c, f.cocktailprices[c])
+			if !ok {
+				continue
+			}
+			if _, err := tokenCount(p, tokens); err != nil {
+				return fmt.Sprintf("%s costs %s during %s, which can not be paid with tokens", c, formatEuro(p), pr.name), nil
+			}
+		}
+	}
+	for _, b := range bundles {
+		if _, err := tokenCount(b.price, tokens); err != nil {
+			return fmt.Sprintf("%s costs %s, which can not be paid with tokens", b.name, formatEuro(b.price)), nil
+		}
+	}
+	return "", nil
+}
+
+func (in *input) chooseToken(db *DB) (token, error) {
+	tokens, err := db.getTokens(cfg.Current)
+	if err != nil {
+		return token{}, err
+	}
+	for i, t := range tokens {
+		fmt.Fprintf(in.w, "%d\t%s\n", i, formatEuro(t.value))
+	}
+
+	sel, err := in.getInt("Which token? ")
+	if err != nil {
+		return token{}, err
+	}
+	if sel < 0 || sel >= len(tokens) {
+		return token{}, fmt.Errorf("%d is not a valid choice", sel)
+	}
+	return tokens[sel], nil
+}
+
+func (in *input) addToken(db *DB) error {
+	value, err := in.getInt("Value of the token [ct]: ")
+	if err != nil {
+		return err
+	}
+	if value <= 0 {
+		return fmt.Errorf("%d is not a valid value", value)
+	}
+
+	_, err = db.Exec("INSERT INTO tokens (fest, value) VALUES ((SELECT id FROM fests WHERE date = $1), $2)", cfg.Current, value)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// sellTokens records tokens sold at the cash desk.
+func (in *input) sellTokens(db *DB) error {
+	t, err := in.chooseToken(db)
+	if err != nil {
+		return err
+	}
+	n, err := in.getInt("How many %s tokens were sold? ", formatEuro(t.value))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE tokens SET sold = sold + $1 WHERE fest = (SELECT id FROM fests WHERE date = $2) AND value = $3", n, cfg.Current, t.value)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// countRedeemed sets how many tokens were collected at the bar.
+func (in *input) countRedeemed(db *DB) error {
+	tokens, err := db.getTokens(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	redeemed := make(map[int]int)
+	for _, t := range tokens {
+		choice, err := in.getString("How many %s tokens were collected? [%d so far, press enter to keep]: ", formatEuro(t.value), t.redeemed)
+		if err != nil {
+			return err
+		}
+		if choice == "" {
+			continue
+		}
+		redeemed[t.value], err = strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for value, n := range redeemed {
+		_, err = tx.Exec("UPDATE tokens SET redeemed = $1 WHERE fest = (SELECT id FROM fests WHERE date = $2) AND value = $3", n, cfg.Current, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (in *input) printTokens(db *DB) error {
+	tokens, err := db.getTokens(cfg.Current)
+	if err != nil {
+		return err
+	}
+	f, err := db.getFest(cfg.Current)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "token\tsold\tredeemed\tunredeemed\n")
+	for _, t := range tokens {
+		fmt.Fprintf(in.w, "%s\t%d\t%d\t%s\n", formatEuro(t.value), t.sold, t.redeemed, formatEuro((t.sold-t.redeemed)*t.value))
+	}
+
+	fmt.Fprintf(in.w, "\ncocktail\tprice\ttokens\n")
+	for _, c := range f.cocktails {
+		count, err := tokenCount(f.cocktailprices[c], tokens)
+		if err != nil {
+			fmt.Fprintf(in.w, "%s\t%s\t%s\n", c, formatEuro(f.cocktailprices[c]), err)
+			continue
+		}
+		fmt.Fprintf(in.w, "%s\t%s\t%s\n", c, formatEuro(f.cocktailprices[c]), formatTokens(count))
+	}
+	return nil
+}
+
+func (in *input) tokenMenu(db *DB) error {
+	items := []string{"show tokens [l]", "add token value [a]", "sell tokens [s]", "count collected tokens [c]", "main Menu [press enter]"}
+	for _, i := range items {
+		fmt.Fprintf(in.w, "%s\n", i)
+	}
+
+	c, err := in.getString("Choice: ")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c == "l":
+		if err = in.printTokens(db); err != nil {
+			return err
+		}
+	case c == "a":
+		if err = in.addToken(db); err != nil {
+			return err
+		}
+	case c == "s":
+		if err = in.sellTokens(db); err != nil {
+			return err
+		}
+	case c == "c":
+		if err = in.countRedeemed(db); err != nil {
+			return err
+		}
+	case c == "":
+		return nil
+	default:
+		return fmt.Errorf("%s is not a valid choice", c)
+	}
+
+	return nil
+}
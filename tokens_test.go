@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenCount(t *testing.T) {
+	tests := []struct {
+		price  int
+		tokens []token
+		want   map[int]int
+		err    bool
+	}{
+		{0, []token{{value: 100}}, map[int]int{}, false},
+		{350, []token{{value: 100}, {value: 50}}, map[int]int{100: 3, 50: 1}, false},
+		// greedily 100 + 6 × 10 would take 7 tokens
+		{160, []token{{value: 100}, {value: 80}, {value: 10}}, map[int]int{80: 2}, false},
+		{120, []token{{value: 100}, {value: 50}}, nil, true},
+		{100, nil, nil, true},
+		{-50, []token{{value: 50}}, nil, true},
+		{100, []token{{value: 0}, {value: 50}}, map[int]int{50: 2}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := tokenCount(tt.price, tt.tokens)
+		if (err != nil) != tt.err {
+			t.Errorf("tokenCount(%d, %v) error = %v, want error %t", tt.price, tt.tokens, err, tt.err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenCount(%d, %v) = %v, want %v", tt.price, tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		count map[int]int
+		want  string
+	}{
+		{map[int]int{100: 2, 50: 1}, "2 × 1,00 €, 1 × 0,50 €"},
+		{map[int]int{50: 1, 100: 0}, "1 × 0,50 €"},
+	}
+
+	for _, tt := range tests {
+		if got := formatTokens(tt.count); got != tt.want {
+			t.Errorf("formatTokens(%v) = %q, want %q", tt.count, got, tt.want)
+		}
+	}
+}
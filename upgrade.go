@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// table is a table of the schema with the definitions of its columns.
+type table struct {
+	name    string
+	create  string
+	columns []string
+}
+
+// parseSchema returns the tables of schema, which is written like
+// schema.sql: CREATE TABLE statements with one column or constraint per line.
+func parseSchema(schema string) ([]table, error) {
+	var tables []table
+	for _, stmt := range strings.Split(schema, ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+
+		lines := strings.Split(stmt, "\n")
+		head := strings.TrimSpace(lines[0])
+		if !strings.HasPrefix(head, "CREATE TABLE ") || !strings.HasSuffix(head, "(") {
+			return nil, fmt.Errorf("the schema can only contain CREATE TABLE statements, not %s", head)
+		}
+
+		t := table{
+			name:   strings.TrimSuffix(strings.TrimPrefix(head, "CREATE TABLE "), "("),
+			create: stmt,
+		}
+		for _, l := range lines[1:] {
+			l = strings.TrimSpace(l)
+			switch {
+			case l == "", l == ")", strings.HasPrefix(l, "--"):
+			case strings.HasPrefix(l, "PRIMARY KEY"), strings.HasPrefix(l, "FOREIGN KEY"), strings.HasPrefix(l, "UNIQUE"):
+			default:
+				t.columns = append(t.columns, strings.TrimSuffix(l, ","))
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// tableColumns returns the columns table has in tx, none if it does not exist.
+func tableColumns(tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var def sql.NullString
+
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &def, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// upgrade adds the tables and columns of the schema in file that are missing
+// in db, so databases created with an older schema can still be used.
+func (db *DB) upgrade(file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	tables, err := parseSchema(string(b))
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, t := range tables {
+		columns, err := tableColumns(tx, t.name)
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			if _, err = tx.Exec(t.create); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, c := range t.columns {
+			if columns[strings.Fields(c)[0]] {
+				continue
+			}
+			if _, err = tx.Exec("ALTER TABLE " + t.name + " ADD COLUMN " + c); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
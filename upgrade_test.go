@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSchema(t *testing.T) {
+	schema := `CREATE TABLE fests(
+	-- fests contains all fests
+
+	-- id is a sequential identifier
+	id INTEGER,
+	-- date is the identifier of the fest
+	date TEXT DEFAULT '',
+	--
+	PRIMARY KEY(id),
+	UNIQUE(date)
+);
+
+CREATE TABLE festhours(
+	fest INTEGER,
+	hour INTEGER,
+	FOREIGN KEY(fest) REFERENCES fests(id)
+);
+`
+	tables, err := parseSchema(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name    string
+		columns []string
+	}{
+		{"fests", []string{"id INTEGER", "date TEXT DEFAULT ''"}},
+		{"festhours", []string{"fest INTEGER", "hour INTEGER"}},
+	}
+	if len(tables) != len(want) {
+		t.Fatalf("parsed %d tables, want %d", len(tables), len(want))
+	}
+	for i, w := range want {
+		if tables[i].name != w.name || !reflect.DeepEqual(tables[i].columns, w.columns) {
+			t.Errorf("table %d = %s %q, want %s %q", i, tables[i].name, tables[i].columns, w.name, w.columns)
+		}
+	}
+
+	if _, err := parseSchema("DROP TABLE fests;"); err == nil {
+		t.Errorf("parsing DROP TABLE succeeded, want an error")
+	}
+}
+
+func TestParseSchemaFile(t *testing.T) {
+	b, err := os.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := parseSchema(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tb := range tables {
+		if len(tb.columns) == 0 {
+			t.Errorf("table %s has no columns", tb.name)
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.sql")
+	err := os.WriteFile(schema, []byte(`CREATE TABLE fests(
+	id INTEGER,
+	date TEXT,
+	guests INTEGER,
+	--
+	PRIMARY KEY(id)
+);
+
+CREATE TABLE tokens(
+	fest INTEGER,
+	value INTEGER DEFAULT 0
+);
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmp, err := sql.Open("sqlite3", filepath.Join(dir, "fest.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &DB{tmp}
+	defer db.Close()
+
+	if _, err = db.Exec("CREATE TABLE fests(id INTEGER, date TEXT, PRIMARY KEY(id))"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec("INSERT INTO fests (date) VALUES ('SS 15')"); err != nil {
+		t.Fatal(err)
+	}
+
+	// upgrading twice changes nothing the second time
+	for i := 0; i < 2; i++ {
+		if err = db.upgrade(schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var date string
+	var guests sql.NullInt64
+	if err = db.QueryRow("SELECT date, guests FROM fests").Scan(&date, &guests); err != nil {
+		t.Fatal(err)
+	}
+	if date != "SS 15" || guests.Valid {
+		t.Errorf("upgraded fest is %s with %v guests, want SS 15 with none", date, guests)
+	}
+	if _, err = db.Exec("INSERT INTO tokens (fest, value) VALUES (1, 100)"); err != nil {
+		t.Errorf("the missing table was not created: %v", err)
+	}
+}
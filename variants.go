@@ -0,0 +1,193 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+type substitution struct {
+	substitute string
+	factor     float64
+}
+
+// substitutions maps the replaced ingredients of a variant to their
+// substitution.
+type substitutions map[string]substitution
+
+// apply returns the ingredient and amount used instead of amount of ing. The
+// returned ingredient is empty if ing is left out.
+func (subs substitutions) apply(ing string, amount float64) (string, float64) {
+	s, ok := subs[ing]
+	if !ok {
+		return ing, amount
+	}
+	return s.substitute, amount * s.factor
+}
+
+// cocktailBase returns the cocktail the given cocktail is a variant of and the
+// factor its recipe is scaled with. base is empty if it is no variant.
+func (db *DB) cocktailBase(cocktail string) (base string, scale float64, err error) {
+	var name sql.NullString
+	err = db.QueryRow("SELECT b.name, c.scale FROM cocktails c LEFT JOIN cocktails b ON b.id = c.base WHERE c.name = $1", cocktail).Scan(&name, &scale)
+	if err != nil {
+		return "", 0, err
+	}
+	return name.String, scale, nil
+}
+
+func (db *DB) substitutions(cocktail string) (substitutions, error) {
+	rows, err := db.Query("SELECT i.name, s.name, variantsubstitutions.factor FROM variantsubstitutions JOIN ingredients i ON i.id = variantsubstitutions.ingredient LEFT JOIN ingredients s ON s.id = variantsubstitutions.substitute WHERE cocktail = (SELECT id FROM cocktails WHERE name = $1)", cocktail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make(substitutions)
+	for rows.Next() {
+		var ing string
+		var substitute sql.NullString
+		var factor float64
+
+		if err := rows.Scan(&ing, &substitute, &factor); err != nil {
+			return nil, err
+		}
+		subs[ing] = substitution{substitute.String, factor}
+	}
+	return subs, rows.Err()
+}
+
+func (in *input) createVariant(db *DB) error {
+	cocktails, err := db.getCocktails()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.w, "Cocktails a variant can be based on:\n")
+	for i, c := range cocktails {
+		base, _, err := db.cocktailBase(c.name)
+		if err != nil {
+			return err
+		}
+		if base == "" {
+			fmt.Fprintf(in.w, "%d\t%s\n", i, c.name)
+		}
+	}
+
+	sel, err := in.getInt("Which cocktail is the variant based on? ")
+	if err != nil {
+		return err
+	}
+	if sel < 0 || sel >= len(cocktails) {
+		return fmt.Errorf("%d is not a valid choice", sel)
+	}
+	base := cocktails[sel]
+	if b, _, err := db.cocktailBase(base.name); err != nil {
+		return err
+	} else if b != "" {
+		return fmt.Errorf("%s is a variant itself", base.name)
+	}
+
+	name, err := in.getString("Name of the variant [e.g. Virgin %s]: ", base.name)
+	if err != nil {
+		return err
+	}
+	scale, err := in.getFloat("Scale the recipe of %s by [e.g. 1.5 for large]: ", base.name)
+	if err != nil {
+		return err
+	}
+	if scale <= 0 {
+		return fmt.Errorf("%.2f is not a valid scale", scale)
+	}
+
+	var baseIngreds []string
+	for ing := range base.ingredients {
+		baseIngreds = append(baseIngreds, ing)
+	}
+	sort.Strings(baseIngreds)
+	ingreds, err := db.getIngredients()
+	if err != nil {
+		return err
+	}
+
+	subs := make(substitutions)
+	for {
+		for i, ing := range baseIngreds {
+			fmt.Fprintf(in.w, "%d\t%s\t%.2f l\n", i, ing, base.ingredients[ing]*scale)
+		}
+		choice, err := in.getString("Which ingredient do you want to replace or leave out? [press enter when done]: ")
+		if err != nil {
+			return err
+		}
+		if choice == "" {
+			break
+		}
+
+		id, err := strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+		if id < 0 || id >= len(baseIngreds) {
+			return fmt.Errorf("%d is not a valid choice", id)
+		}
+
+		for i, ing := range ingreds {
+			fmt.Fprintf(in.w, "%d\t%s\n", i, ing)
+		}
+		choice, err = in.getString("Replace %s with [press enter to leave it out]: ", baseIngreds[id])
+		if err != nil {
+			return err
+		}
+		if choice == "" {
+			subs[baseIngreds[id]] = substitution{}
+			continue
+		}
+
+		sub, err := strconv.Atoi(choice)
+		if err != nil {
+			return err
+		}
+		if sub < 0 || sub >= len(ingreds) {
+			return fmt.Errorf("%d is not a valid choice", sub)
+		}
+		factor, err := in.getFloat("How much %s replaces 1 l of %s? [l]: ", ingreds[sub], baseIngreds[id])
+		if err != nil {
+			return err
+		}
+		subs[baseIngreds[id]] = substitution{ingreds[sub], factor}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO cocktails (name, method, glass, garnish, instructions, base, scale) VALUES ($1, $2, $3, $4, $5, (SELECT id FROM cocktails WHERE name = $6), $7)", name, base.method, base.glass, base.garnish, base.instructions, base.name, scale)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for ing, s := range subs {
+		var substitute interface{}
+		if s.substitute != "" {
+			substitute = s.substitute
+		}
+
+		_, err := tx.Exec("INSERT INTO variantsubstitutions (cocktail, ingredient, substitute, factor) VALUES ($1, (SELECT id FROM ingredients WHERE name = $2), (SELECT id FROM ingredients WHERE name = $3), $4)", id, ing, substitute, s.factor)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}